@@ -0,0 +1,72 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"io/ioutil"
+)
+
+// graphFormats maps each format of the graph subcommand to the extension of
+// its default output file.
+var graphFormats = map[string]string{
+	"dot":     ".dot",
+	"mermaid": ".mmd",
+	"json":    ".json",
+}
+
+// runGraph runs the graph subcommand.
+func runGraph(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("dibuilder graph", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "", "the `file` name of the output file (default \"graph\" with the extension for the format)")
+	format := flags.String("format", "dot", "the `format` of the graph: dot, mermaid or json")
+	load := addLoadFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: dibuilder graph [flags] pattern...\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no package patterns given")
+	}
+	ext, ok := graphFormats[*format]
+	if !ok {
+		return fmt.Errorf("unknown graph format %q", *format)
+	}
+	if *output == "" {
+		*output = "graph" + ext
+	}
+
+	fileSet := token.NewFileSet()
+	container, _, err := load.load(fileSet, flags.Args(), stderr)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	switch *format {
+	case "dot":
+		err = container.WriteDOT(&buffer)
+	case "mermaid":
+		err = container.WriteMermaid(&buffer)
+	case "json":
+		err = container.WriteJSON(&buffer, fileSet)
+	}
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(*output, buffer.Bytes(), 0666)
+}
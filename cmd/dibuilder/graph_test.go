@@ -0,0 +1,73 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunGraphWritesDOTFile(t *testing.T) {
+	inTestModule(t, testModule, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"graph", "example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "graph.dot"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "digraph dependencies {\n")
+		assert.Contains(t, string(content), `[label="config.NewConfig"];`)
+		assert.Contains(t, string(content), `[label="server.NewServer"];`)
+		assert.Contains(t, string(content), `[label="Root", shape=doublecircle];`)
+		assert.Contains(t, string(content), `[label="config.Config"];`)
+	})
+}
+
+func TestRunGraphWithoutPatternsIsError(t *testing.T) {
+	var stderr bytes.Buffer
+	err := run([]string{"graph"}, ioutil.Discard, &stderr)
+
+	assert.Error(t, err)
+	assert.Contains(t, stderr.String(), "usage: dibuilder graph")
+}
+
+func TestRunGraphWritesMermaidFile(t *testing.T) {
+	inTestModule(t, testModule, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"graph", "-format=mermaid", "example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "graph.mmd"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "flowchart TD\n")
+		assert.Contains(t, string(content), `["server.NewServer"]`)
+	})
+}
+
+func TestRunGraphWritesJSONFile(t *testing.T) {
+	inTestModule(t, testModule, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"graph", "-format", "json", "-o", "wiring.json",
+			"example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "wiring.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"package": "example.com/myproject/components/server"`)
+		assert.Contains(t, string(content), `"filename": "`)
+	})
+}
+
+func TestRunGraphWithUnknownFormatIsError(t *testing.T) {
+	var stderr bytes.Buffer
+	err := run([]string{"graph", "-format=svg", "example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+
+	assert.EqualError(t, err, `unknown graph format "svg"`)
+}
@@ -0,0 +1,77 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+
+	"github.com/sbosnick/dibuilder/depend"
+)
+
+// runImpact runs the impact subcommand.
+func runImpact(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("dibuilder impact", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	load := addLoadFlags(flags)
+	entry := flags.String("entry", "", "list only the dependents from which the root of the entry point `name` can be reached")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: dibuilder impact [flags] decl pattern...\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errors.New("no declaration or package patterns given")
+	}
+
+	fileSet := token.NewFileSet()
+	name := flags.Arg(0)
+	container, result, err := load.load(fileSet, flags.Args()[1:], stderr)
+	if err != nil {
+		return err
+	}
+	decl := result.LookupDecl(name)
+	if decl == nil {
+		return fmt.Errorf("unknown declaration %s", name)
+	}
+	node, err := container.NodeFor(decl)
+	if err != nil {
+		return fmt.Errorf("%s is not a provider", name)
+	}
+
+	distance := 0
+	for _, dependent := range container.Dependents(node) {
+		if *entry != "" && !hasEntryPoint(dependent, *entry) {
+			continue
+		}
+		if dependent.Distance != distance {
+			distance = dependent.Distance
+			fmt.Fprintf(stdout, "distance %d\n", distance)
+		}
+		if dependent.Decl == nil {
+			fmt.Fprintf(stdout, "\troot %s\n", dependent.EntryPoints[0])
+			continue
+		}
+		fmt.Fprintf(stdout, "\t%s at %s\n", qualifiedName(dependent.Decl), fileSet.Position(dependent.Decl.Pos()))
+	}
+
+	return nil
+}
+
+func hasEntryPoint(dependent depend.Dependent, entry string) bool {
+	for _, name := range dependent.EntryPoints {
+		if name == entry {
+			return true
+		}
+	}
+	return false
+}
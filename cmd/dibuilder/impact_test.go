@@ -0,0 +1,95 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunImpactPrintsDependentsByDistance(t *testing.T) {
+	inTestModule(t, whyModule, func(dir string) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"impact", "kafka.NewClient", "example.com/myproject/components/..."}, &stdout, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		assert.Regexp(t, `^distance 1
+	server.NewEvents at .*server.go:10:6
+distance 2
+	server.NewServer at .*server.go:16:6
+distance 3
+	root Root
+$`, stdout.String())
+	})
+}
+
+func TestRunImpactLimitsDependentsToEntryPoint(t *testing.T) {
+	inTestModule(t, workerModule, func(dir string) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"impact", "-entry", "APIServer",
+			"-root", "APIServer=*server.Server", "-root", "Worker=*worker.Worker",
+			"config.NewConfig", "example.com/myproject/components/..."}, &stdout, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		assert.Regexp(t, `^distance 1
+	server.NewServer at .*server.go:9:6
+distance 2
+	root APIServer
+$`, stdout.String())
+	})
+}
+
+func TestRunImpactOfUnknownDeclarationIsError(t *testing.T) {
+	inTestModule(t, whyModule, func(dir string) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"impact", "kafka.NewMissing", "example.com/myproject/components/..."}, &stdout, &stderr)
+
+		assert.EqualError(t, err, "unknown declaration kafka.NewMissing")
+	})
+}
+
+func TestRunImpactPrintsDependentsOfValue(t *testing.T) {
+	module := map[string]string{
+		"go.mod":                      testModule["go.mod"],
+		"components/config/config.go": testModule["components/config/config.go"],
+		"components/server/server.go": `package server
+
+import "example.com/myproject/components/config"
+
+type Port int
+
+//dibuilder:provide
+const DefaultPort Port = 8080
+
+//dibuilder:inject
+type Handler struct {
+	Config config.Config
+	Port   Port
+}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+func NewServer(handler *Handler) *Server { return &Server{} }
+`,
+	}
+	inTestModule(t, module, func(dir string) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"impact", "server.DefaultPort", "example.com/myproject/components/..."}, &stdout, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		assert.Regexp(t, `^distance 1
+	server.Handler at .*server.go:11:6
+distance 2
+	server.NewServer at .*server.go:20:6
+distance 3
+	root Root
+$`, stdout.String())
+	})
+}
@@ -0,0 +1,286 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+// Command dibuilder generates a dependency injection builder function.
+//
+// Usage:
+//
+//	dibuilder [flags] pattern...
+//	dibuilder graph [flags] pattern...
+//	dibuilder why [flags] type pattern...
+//	dibuilder impact [flags] decl pattern...
+//
+// dibuilder loads the packages matched by the patterns, adds each exported
+// top-level function whose name starts with "New", each struct type annotated
+// with "//dibuilder:inject" and each package-level variable or constant
+// annotated with "//dibuilder:provide" to a depend.Container and writes a
+// builder function for that Container to a file in the current directory.
+// It is intended to be run from a go:generate directive such as
+//
+//	//go:generate dibuilder github.com/sbosnick/myproject/internal/components/...
+//
+// The flags are:
+//
+//	-o file
+//		the name of the output file (default "buildroot.go")
+//	-func name
+//		the name of the builder function (default "buildRoot")
+//	-package name
+//		the package name of the output file (default $GOPACKAGE or "main")
+//	-prefix prefix
+//		the name prefix that identifies a constructor (default "New")
+//	-root [name=]pkg.Type
+//		the root type, named by the import path or name of its package and
+//		its name, with a leading "*" for a pointer type (default the one
+//		type with a Run method); the flag can be repeated with a different
+//		name for each entry point, each of which gets a builder function
+//		named "build" followed by its name
+//	-autobind
+//		satisfy an interface requirement with the one provided type that
+//		implements it
+//	-pruned
+//		list the constructors that no builder function calls
+//
+// Constructors from which no root can be reached are pruned: they are not
+// called by the builder functions and their requirements need not be met.
+//
+// The graph subcommand writes the dependency graph of the constructors to a
+// file instead of writing a builder function. It takes the -prefix, -root and
+// -autobind flags above and
+//
+//	-format format
+//		the format of the graph: "dot" for the Graphviz DOT language,
+//		"mermaid" for a Mermaid flowchart or "json" for a versioned JSON
+//		document (see depend.Container.WriteJSON) (default "dot")
+//	-o file
+//		the name of the output file (default "graph.dot", "graph.mmd" or
+//		"graph.json" for the format)
+//
+// The why subcommand prints every path along which a root requires the
+// component type, listing each constructor on the path with the component it
+// provides and its position. type is named as for -root. It takes the
+// -prefix, -root and -autobind flags above.
+//
+// The impact subcommand prints the declarations and roots that depend,
+// directly or transitively, on the components provided by decl, grouped by
+// their distance from decl. decl is a constructor, an annotated struct type or
+// an annotated variable or constant, named by the import path or name of its
+// package and its name. It takes the -prefix, -root and -autobind flags above
+// and
+//
+//	-entry name
+//		list only the dependents from which the root of the entry point
+//		name can be reached ("Root" for the root that is not named)
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/sbosnick/dibuilder/depend"
+	"github.com/sbosnick/dibuilder/loader"
+)
+
+const defaultOutput = "buildroot.go"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "dibuilder: %v\n", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "graph":
+			return runGraph(args[1:], stderr)
+		case "why":
+			return runWhy(args[1:], stdout, stderr)
+		case "impact":
+			return runImpact(args[1:], stdout, stderr)
+		}
+	}
+
+	flags := flag.NewFlagSet("dibuilder", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", defaultOutput, "the `file` name of the output file")
+	funcName := flags.String("func", depend.DefaultFuncName, "the `name` of the builder function")
+	pkgName := flags.String("package", defaultPackageName(), "the package `name` of the output file")
+	load := addLoadFlags(flags)
+	listPruned := flags.Bool("pruned", false, "list the constructors that no builder function calls")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: dibuilder [flags] pattern...\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no package patterns given")
+	}
+
+	fileSet := token.NewFileSet()
+	container, _, err := load.load(fileSet, flags.Args(), stderr)
+	if err != nil {
+		return err
+	}
+
+	if *listPruned {
+		for _, obj := range container.Pruned() {
+			fmt.Fprintf(stderr, "%s: pruned %s\n", fileSet.Position(obj.Pos()), qualifiedName(obj))
+		}
+	}
+
+	if errs := container.Validate(); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(stderr, "%s\n", err.ErrorWithPosition(fileSet))
+		}
+		return errors.New("unable to build the components")
+	}
+
+	var buffer bytes.Buffer
+	err = container.WriteBuilder(&buffer, depend.BuilderOptions{
+		PackageName: *pkgName,
+		PackagePath: loader.PackagePath(""),
+		FuncName:    *funcName,
+	})
+	if err != nil {
+		return positionError(fileSet, err)
+	}
+
+	return ioutil.WriteFile(*output, buffer.Bytes(), 0666)
+}
+
+// loadFlags are the flags that control the loading of the constructors.
+type loadFlags struct {
+	prefix   *string
+	roots    rootFlag
+	autoBind *bool
+}
+
+func addLoadFlags(flags *flag.FlagSet) *loadFlags {
+	lf := &loadFlags{}
+	lf.prefix = flags.String("prefix", loader.DefaultPrefix, "the name `prefix` that identifies a constructor")
+	flags.Var(&lf.roots, "root", "the root type, as `[name=]pkg.Type`, repeated for each named entry point (default the one type with a Run method)")
+	lf.autoBind = flags.Bool("autobind", false, "satisfy an interface requirement with the one provided type that implements it")
+	return lf
+}
+
+// load adds the constructors in the packages matched by patterns to a new
+// Container. It reports the constructors that it skips to stderr.
+func (lf *loadFlags) load(fileSet *token.FileSet, patterns []string, stderr io.Writer) (*depend.Container, *loader.Result, error) {
+	container := &depend.Container{}
+	container.AutoBind(*lf.autoBind)
+	result, err := loader.Load(&loader.Config{
+		Prefix:     *lf.prefix,
+		Fset:       fileSet,
+		Root:       lf.roots.root,
+		NamedRoots: lf.roots.named,
+	}, container, patterns...)
+	var loadErr *loader.LoadError
+	if errors.As(err, &loadErr) {
+		for _, err := range loadErr.Errors {
+			fmt.Fprintf(stderr, "%s\n", err)
+		}
+	}
+	if err != nil {
+		return nil, nil, positionError(fileSet, err)
+	}
+	for _, err := range result.Errors {
+		fmt.Fprintf(stderr, "dibuilder: skipping %s\n", err.ErrorWithPosition(fileSet))
+	}
+
+	return container, result, nil
+}
+
+// rootFlag is the value of the repeatable -root flag. A value of the form
+// "name=pkg.Type" adds a named root and any other value sets the root.
+type rootFlag struct {
+	root  string
+	named map[string]string
+}
+
+func (r *rootFlag) String() string {
+	if r == nil {
+		return ""
+	}
+	return r.root
+}
+
+func (r *rootFlag) Set(value string) error {
+	eq := strings.Index(value, "=")
+	if eq < 0 {
+		if r.root != "" {
+			return errors.New("root given more than once")
+		}
+		r.root = value
+		return nil
+	}
+
+	name := value[:eq]
+	if _, ok := r.named[name]; ok {
+		return fmt.Errorf("root %s given more than once", name)
+	}
+	if r.named == nil {
+		r.named = make(map[string]string)
+	}
+	r.named[name] = value[eq+1:]
+	return nil
+}
+
+// defaultPackageName returns the name of the package for the file that holds
+// the go:generate directive that invoked dibuilder, if any.
+func defaultPackageName() string {
+	if name := os.Getenv("GOPACKAGE"); name != "" {
+		return name
+	}
+
+	return depend.DefaultPackageName
+}
+
+// positionError converts a depend.Error into an error whose message starts with
+// the position of the error. Other errors are returned unchanged.
+func positionError(fileSet *token.FileSet, err error) error {
+	if err, ok := err.(depend.Error); ok {
+		return errors.New(err.ErrorWithPosition(fileSet))
+	}
+
+	return err
+}
+
+// qualifiedName returns the name of obj qualified by the name of its package,
+// or just the name of obj if it does not belong to a package.
+func qualifiedName(obj types.Object) string {
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Name() + "." + obj.Name()
+}
+
+// componentList returns the names of the components typs, qualified by the
+// names of their packages and separated by commas.
+func componentList(typs []types.Type) string {
+	names := make([]string, 0, len(typs))
+	for _, typ := range typs {
+		names = append(names, depend.ComponentString(typ, packageName))
+	}
+	return strings.Join(names, ", ")
+}
+
+func packageName(pkg *types.Package) string {
+	return pkg.Name()
+}
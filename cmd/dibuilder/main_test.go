@@ -0,0 +1,280 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testModule = map[string]string{
+	"go.mod": "module example.com/myproject\n\ngo 1.12\n",
+	"main.go": `package main
+
+//go:generate dibuilder example.com/myproject/components/...
+
+func main() {
+	buildRoot().Run()
+}
+`,
+	"components/config/config.go": `package config
+
+type Config struct{ Addr string }
+
+func NewConfig() Config { return Config{} }
+`,
+	"components/server/server.go": `package server
+
+import "example.com/myproject/components/config"
+
+type Server struct{ addr string }
+
+func (s *Server) Run() {}
+
+func NewServer(config config.Config) *Server { return &Server{addr: config.Addr} }
+`,
+}
+
+// inTestModule creates a module from files in a temporary directory and
+// calls fn with that directory as the current directory.
+func inTestModule(t *testing.T, files map[string]string, fn func(dir string)) {
+	dir, err := ioutil.TempDir("", "dibuilder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0666))
+	}
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	fn(dir)
+}
+
+func TestRunWritesBuilderFile(t *testing.T) {
+	inTestModule(t, testModule, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, defaultOutput))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "package main\n")
+		assert.Contains(t, string(content), "func buildRoot() *server.Server {\n")
+		assert.Contains(t, string(content), "\tconfig_A := config.NewConfig()\n")
+		assert.Contains(t, string(content), "\tserver_A := server.NewServer(config_A)\n")
+	})
+}
+
+func TestRunUsesFlags(t *testing.T) {
+	inTestModule(t, testModule, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"-o", "builder.go", "-func", "build", "-package", "app",
+			"example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "builder.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "package app\n")
+		assert.Contains(t, string(content), "func build() *server.Server {\n")
+	})
+}
+
+func TestRunWithoutPatternsIsError(t *testing.T) {
+	var stderr bytes.Buffer
+	err := run(nil, ioutil.Discard, &stderr)
+
+	assert.Error(t, err)
+	assert.Contains(t, stderr.String(), "usage: dibuilder")
+}
+
+func TestRunReportsMissingDependencies(t *testing.T) {
+	files := map[string]string{
+		"go.mod":                      testModule["go.mod"],
+		"components/server/server.go": testModule["components/server/server.go"],
+		"components/config/config.go": "package config\n\ntype Config struct{ Addr string }\n",
+	}
+	inTestModule(t, files, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "no provider for config.Config")
+		assert.Contains(t, stderr.String(), "required by server.NewServer at ")
+		_, statErr := os.Stat(filepath.Join(dir, defaultOutput))
+		assert.True(t, os.IsNotExist(statErr))
+	})
+}
+
+func TestRunAutoBindsInterfaces(t *testing.T) {
+	files := map[string]string{
+		"go.mod": testModule["go.mod"],
+		"components/sink/sink.go": `package sink
+
+import "io"
+
+type App struct{ w io.Writer }
+
+func (a *App) Run() {}
+
+func NewApp(w io.Writer) *App { return &App{w: w} }
+
+type FileSink struct{}
+
+func (f *FileSink) Write(p []byte) (int, error) { return len(p), nil }
+
+func NewFileSink() *FileSink { return &FileSink{} }
+`,
+	}
+	inTestModule(t, files, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"-autobind", "example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, defaultOutput))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "\tapp := sink.NewApp(fileSink)\n")
+	})
+}
+
+var workerModule = map[string]string{
+	"go.mod":                      testModule["go.mod"],
+	"components/config/config.go": testModule["components/config/config.go"],
+	"components/server/server.go": testModule["components/server/server.go"],
+	"components/worker/worker.go": `package worker
+
+type Worker struct{}
+
+func (w *Worker) Run() {}
+
+func NewWorker() *Worker { return &Worker{} }
+`,
+}
+
+func TestRunReportsAmbiguousRoot(t *testing.T) {
+	inTestModule(t, workerModule, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "Ambiguous root")
+		assert.Contains(t, stderr.String(), "*server.Server from server.NewServer at ")
+		assert.Contains(t, stderr.String(), "*worker.Worker from worker.NewWorker at ")
+	})
+}
+
+func TestRunUsesRootFlag(t *testing.T) {
+	for _, root := range []string{"worker.Worker", "example.com/myproject/components/worker.Worker"} {
+		inTestModule(t, workerModule, func(dir string) {
+			var stderr bytes.Buffer
+			err := run([]string{"-root", "*" + root, "example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+			require.NoError(t, err, stderr.String())
+
+			content, err := ioutil.ReadFile(filepath.Join(dir, defaultOutput))
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "func buildRoot() *worker.Worker {\n")
+		})
+	}
+}
+
+func TestRunWithUnknownRootIsError(t *testing.T) {
+	inTestModule(t, workerModule, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"-root", "worker.Missing", "example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "worker.Missing")
+	})
+}
+
+func TestRunPrintsPackageErrors(t *testing.T) {
+	module := map[string]string{
+		"go.mod":    testModule["go.mod"],
+		"broken.go": "package broken\n\nfunc NewBroken() Undefined { return nil }\n",
+	}
+	inTestModule(t, module, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"."}, ioutil.Discard, &stderr)
+
+		assert.EqualError(t, err, "unable to load packages")
+		assert.Contains(t, stderr.String(), "broken.go:3:18: undefined: Undefined")
+	})
+}
+
+func TestRunWritesBuilderForEachNamedRoot(t *testing.T) {
+	inTestModule(t, workerModule, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{
+			"-root", "APIServer=*server.Server",
+			"-root", "Worker=*example.com/myproject/components/worker.Worker",
+			"example.com/myproject/components/...",
+		}, ioutil.Discard, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, defaultOutput))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "func buildAPIServer() *server.Server {\n")
+		assert.Contains(t, string(content), "func buildWorker() *worker.Worker {\n")
+		assert.NotContains(t, string(content), "func buildRoot()")
+	})
+}
+
+func TestRunNamesEntryPointOfMissingDependency(t *testing.T) {
+	files := map[string]string{
+		"go.mod":                      workerModule["go.mod"],
+		"components/server/server.go": workerModule["components/server/server.go"],
+		"components/worker/worker.go": workerModule["components/worker/worker.go"],
+		"components/config/config.go": "package config\n\ntype Config struct{ Addr string }\n",
+	}
+	inTestModule(t, files, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{
+			"-root", "APIServer=*server.Server",
+			"-root", "Worker=*worker.Worker",
+			"example.com/myproject/components/...",
+		}, ioutil.Discard, &stderr)
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "no provider for config.Config")
+		assert.Contains(t, stderr.String(), "entry points: APIServer\n")
+	})
+}
+
+func TestRunListsPrunedConstructors(t *testing.T) {
+	files := map[string]string{
+		"go.mod":                      testModule["go.mod"],
+		"components/config/config.go": testModule["components/config/config.go"],
+		"components/server/server.go": testModule["components/server/server.go"],
+		"components/cache/cache.go": `package cache
+
+type Metrics struct{}
+
+type Cache struct{}
+
+func NewCache(metrics *Metrics) *Cache { return &Cache{} }
+`,
+	}
+	inTestModule(t, files, func(dir string) {
+		var stderr bytes.Buffer
+		err := run([]string{"-pruned", "example.com/myproject/components/..."}, ioutil.Discard, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		assert.Contains(t, stderr.String(), "cache.go:7:6: pruned cache.NewCache\n")
+		content, err := ioutil.ReadFile(filepath.Join(dir, defaultOutput))
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "NewCache")
+	})
+}
@@ -0,0 +1,60 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+)
+
+// runWhy runs the why subcommand.
+func runWhy(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("dibuilder why", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	load := addLoadFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: dibuilder why [flags] type pattern...\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errors.New("no type or package patterns given")
+	}
+
+	fileSet := token.NewFileSet()
+	name := flags.Arg(0)
+	container, result, err := load.load(fileSet, flags.Args()[1:], stderr)
+	if err != nil {
+		return err
+	}
+	typ := result.LookupType(name)
+	if typ == nil {
+		return fmt.Errorf("unknown type %s", name)
+	}
+
+	paths := container.PathsFromRoot(typ)
+	if len(paths) == 0 {
+		return fmt.Errorf("no root requires %s", name)
+	}
+	for i, path := range paths {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		fmt.Fprintf(stdout, "%s\n", path.EntryPoint)
+		for _, step := range path.Steps {
+			fmt.Fprintf(stdout, "\t%s provides %s at %s\n",
+				qualifiedName(step.Decl), componentList(step.Types), fileSet.Position(step.Decl.Pos()))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var whyModule = map[string]string{
+	"go.mod":                      testModule["go.mod"],
+	"components/config/config.go": testModule["components/config/config.go"],
+	"components/kafka/kafka.go": `package kafka
+
+import "example.com/myproject/components/config"
+
+type Client struct{}
+
+func NewClient(config config.Config) *Client { return &Client{} }
+`,
+	"components/server/server.go": `package server
+
+import (
+	"example.com/myproject/components/config"
+	"example.com/myproject/components/kafka"
+)
+
+type Events struct{}
+
+func NewEvents(client *kafka.Client) *Events { return &Events{} }
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+func NewServer(config config.Config, events *Events) *Server { return &Server{} }
+`,
+}
+
+func TestRunWhyPrintsPathsToType(t *testing.T) {
+	inTestModule(t, whyModule, func(dir string) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"why", "config.Config", "example.com/myproject/components/..."}, &stdout, &stderr)
+		require.NoError(t, err, stderr.String())
+
+		assert.Regexp(t, `^Root
+	server.NewServer provides \*server.Server at .*server.go:16:6
+	config.NewConfig provides config.Config at .*config.go:5:6
+
+Root
+	server.NewServer provides \*server.Server at .*server.go:16:6
+	server.NewEvents provides \*server.Events at .*server.go:10:6
+	kafka.NewClient provides \*kafka.Client at .*kafka.go:7:6
+	config.NewConfig provides config.Config at .*config.go:5:6
+$`, stdout.String())
+	})
+}
+
+func TestRunWhyOfUnknownTypeIsError(t *testing.T) {
+	inTestModule(t, whyModule, func(dir string) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"why", "*kafka.Missing", "example.com/myproject/components/..."}, &stdout, &stderr)
+
+		assert.EqualError(t, err, "unknown type *kafka.Missing")
+	})
+}
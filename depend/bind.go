@@ -0,0 +1,141 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Bind binds the interface type iface to the type concrete. A requirement for
+// iface is then satisfied by the provider of concrete, both in the edges of the
+// Container and in the generated builder. The binding also applies to the
+// qualified components of iface: a requirement for iface qualified by a name
+// is satisfied by the provider of concrete qualified by the same name. Bind
+// returns ErrInvalidBinding if iface is not an interface type or if concrete
+// does not implement iface, and ErrAlreadyBound if iface is already bound to a
+// different type.
+func (c *Container) Bind(iface types.Type, concrete types.Type) error {
+	underlying, ok := iface.Underlying().(*types.Interface)
+	if !ok || !types.Implements(concrete, underlying) {
+		return ErrInvalidBinding
+	}
+
+	if bound, ok := c.bindings.At(iface).(types.Type); ok {
+		if types.Identical(bound, concrete) {
+			return nil
+		}
+		return ErrAlreadyBound
+	}
+
+	c.bindings.Set(iface, concrete)
+	c.resetKeys()
+	return nil
+}
+
+// AutoBind sets whether the Container automatically binds interface types.
+// With automatic binding enabled, a requirement for an interface type that is
+// neither bound by Bind nor provided directly is satisfied by the provider of
+// the one provided type that is assignable to the interface type. If no
+// provided type is assignable to the interface type then the requirement is
+// missing and if more than one is then its providers are ambiguous. Only
+// provided components with the same qualifier as the requirement, if any, are
+// considered.
+func (c *Container) AutoBind(enabled bool) {
+	c.autoBind = enabled
+	c.resetKeys()
+}
+
+// resolve returns the type whose provider satisfies a requirement for typ.
+// This is the one type from keysFor, if there is only one, or typ itself.
+func (c *Container) resolve(typ types.Type) types.Type {
+	if keys := c.keysFor(typ); len(keys) == 1 {
+		return keys[0]
+	}
+
+	return typ
+}
+
+// keysFor returns the types whose providers can satisfy a requirement for typ.
+// This is the type bound to typ, if any, or the provided types assignable to
+// typ if typ is an interface type that is not itself provided and automatic
+// binding is enabled, or otherwise typ itself. For a qualified typ, the
+// binding is that of its unqualified type and the provided types are those
+// with the same qualifier. The result is cached until resetKeys is called.
+func (c *Container) keysFor(typ types.Type) []types.Type {
+	if c.keys == nil {
+		c.keys = &typeutil.Map{}
+	}
+	if keys, ok := c.keys.At(typ).([]types.Type); ok {
+		return keys
+	}
+
+	keys := c.findKeys(typ)
+	c.keys.Set(typ, keys)
+	return keys
+}
+
+// resetKeys discards the cached results of keysFor. It must be called
+// whenever the provided types, the bindings or automatic binding change.
+func (c *Container) resetKeys() {
+	c.keys = nil
+}
+
+// findKeys computes the result of keysFor for typ.
+func (c *Container) findKeys(typ types.Type) []types.Type {
+	iface, name := Unqualified(typ)
+	if bound, ok := c.bindings.At(iface).(types.Type); ok {
+		return []types.Type{Qualified(bound, name)}
+	}
+
+	if !c.autoBind || !types.IsInterface(iface) || len(c.providedBy.Nodes(typ)) > 0 {
+		return []types.Type{typ}
+	}
+
+	var keys []types.Type
+	for _, provided := range c.providedBy.Types() {
+		unqualified, qualifier := Unqualified(provided)
+		if qualifier == name && types.AssignableTo(unqualified, iface) {
+			keys = append(keys, provided)
+		}
+	}
+	if len(keys) == 0 {
+		return []types.Type{typ}
+	}
+
+	return sortTypes(keys)
+}
+
+// requiredKeys returns the distinct resolved types of the requirements of the
+// nodes of the Container sorted by name.
+func (c *Container) requiredKeys() []types.Type {
+	var keys []types.Type
+
+	for _, typ := range c.requiredBy.Types() {
+		key := c.resolve(typ)
+		if _, found := findType(keys, key); !found {
+			keys = append(keys, key)
+		}
+	}
+
+	return sortTypes(keys)
+}
+
+// requirersOf returns the nodes with a requirement that resolves to key.
+func (c *Container) requirersOf(key types.Type) []commonNode {
+	var nodes []commonNode
+
+	for _, typ := range c.requiredBy.Types() {
+		if types.Identical(c.resolve(typ), key) {
+			nodes = append(nodes, c.requiredBy.Nodes(typ)...)
+		}
+	}
+
+	// keep the nodes in the order they were added to the Container
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	return nodes
+}
@@ -0,0 +1,326 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bindTestSrc = `package components
+
+type Writer interface {
+	Write(p []byte) (int, error)
+}
+
+type FileSink struct{}
+
+func (f *FileSink) Write(p []byte) (int, error) { return 0, nil }
+
+type Handler struct{}
+
+func (h *Handler) Run() {}
+
+func NewHandler(w Writer) *Handler { return nil }
+
+func NewFileSink() *FileSink { return nil }
+`
+
+func lookupType(pkg *types.Package, name string) types.Type {
+	return pkg.Scope().Lookup(name).Type()
+}
+
+func TestContainerBindSatisfiesInterfaceRequirement(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	sink := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewFileSink").(*types.Func))
+	handler := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewHandler").(*types.Func))
+
+	err := sut.Bind(lookupType(pkg, "Writer"), types.NewPointer(lookupType(pkg, "FileSink")))
+
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{sink}, toInterfaces(sut.To(handler)))
+	assert.True(t, sut.HasEdgeFromTo(sink, handler))
+	assert.Empty(t, sut.Validate())
+}
+
+func TestContainerWithoutBindIsIncomplete(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 1)
+	assert.Equal(t, lookupType(pkg, "Writer"), errs[0].(*MissingDependencyError).Type)
+}
+
+func TestContainerBindOfNonImplementingTypeIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+
+	err := sut.Bind(lookupType(pkg, "Writer"), lookupType(pkg, "FileSink"))
+
+	assert.Equal(t, ErrInvalidBinding, err)
+}
+
+func TestContainerBindOfNonInterfaceIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+
+	err := sut.Bind(lookupType(pkg, "Handler"), lookupType(pkg, "Handler"))
+
+	assert.Equal(t, ErrInvalidBinding, err)
+}
+
+func TestContainerRebindIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	writer := lookupType(pkg, "Writer")
+	sink := types.NewPointer(lookupType(pkg, "FileSink"))
+	sut := &Container{}
+
+	first := sut.Bind(writer, sink)
+	same := sut.Bind(writer, sink)
+	other := sut.Bind(writer, writer)
+
+	assert.NoError(t, first)
+	assert.NoError(t, same)
+	assert.Equal(t, ErrAlreadyBound, other)
+}
+
+func TestWriteBuilderPassesBoundProvider(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	_ = sut.Bind(lookupType(pkg, "Writer"), types.NewPointer(lookupType(pkg, "FileSink")))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "\tfileSink := components.NewFileSink()\n")
+	assert.Contains(t, out.String(), "\thandler := components.NewHandler(fileSink)\n")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func toInterfaces(nodes []graph.Node) []interface{} {
+	var result []interface{}
+	for _, node := range nodes {
+		result = append(result, node)
+	}
+	return result
+}
+
+const autoBindTestSrc = `package components
+
+type Writer interface {
+	Write(p []byte) (int, error)
+}
+
+type Reader interface {
+	Read(p []byte) (int, error)
+}
+
+type FileSink struct{}
+
+func (f *FileSink) Write(p []byte) (int, error) { return 0, nil }
+
+type NetSink struct{}
+
+func (n *NetSink) Write(p []byte) (int, error) { return 0, nil }
+
+type Handler struct{}
+
+func (h *Handler) Run() {}
+
+func NewHandler(w Writer) *Handler { return nil }
+
+func NewFileSink() *FileSink { return nil }
+
+func NewSource(r Reader) int { return 0 }
+`
+
+func TestContainerAutoBindSatisfiesInterfaceWithOneImplementation(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+	sut.AutoBind(true)
+	addConstructors(t, sut, pkg)
+	sink := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewFileSink").(*types.Func))
+	handler := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewHandler").(*types.Func))
+
+	nodes := sut.To(handler)
+
+	assert.Equal(t, []interface{}{sink}, toInterfaces(nodes))
+	assert.True(t, sut.HasEdgeFromTo(sink, handler))
+	assert.Empty(t, sut.Validate())
+}
+
+func TestContainerAutoBindReportsMissingAndAmbiguousInterfaces(t *testing.T) {
+	src := autoBindTestSrc + "\nfunc NewNetSink() *NetSink { return nil }\n"
+	pkg, _ := loadTestPackage(t, testComponentsPath, src)
+	sut := &Container{}
+	sut.AutoBind(true)
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddRoot("Handler", types.NewPointer(lookupType(pkg, "Handler"))))
+	require.NoError(t, sut.AddRoot("Source", types.Typ[types.Int]))
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 2)
+	missing := errs[0].(*MissingDependencyError)
+	assert.Equal(t, lookupType(pkg, "Reader"), missing.Type)
+	ambiguous := errs[1].(*AmbiguousProviderError)
+	assert.Equal(t, lookupType(pkg, "Writer"), ambiguous.Type)
+	require.Len(t, ambiguous.Providers, 2)
+	assert.Equal(t, "NewFileSink", ambiguous.Providers[0].Name())
+	assert.Equal(t, "NewNetSink", ambiguous.Providers[1].Name())
+}
+
+func TestContainerAutoBindSeesProvidersAddedAfterValidate(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+	sut.AutoBind(true)
+	require.NoError(t, sut.AddFunc(pkg.Scope().Lookup("NewHandler").(*types.Func)))
+	before := sut.Validate()
+
+	require.NoError(t, sut.AddFunc(pkg.Scope().Lookup("NewFileSink").(*types.Func)))
+	after := sut.Validate()
+
+	assert.Len(t, before, 1)
+	assert.Empty(t, after)
+}
+
+func TestContainerAutoBindPrefersExplicitBinding(t *testing.T) {
+	src := autoBindTestSrc + "\nfunc NewNetSink() *NetSink { return nil }\n"
+	pkg, _ := loadTestPackage(t, testComponentsPath, src)
+	sut := &Container{}
+	sut.AutoBind(true)
+	addConstructors(t, sut, pkg)
+
+	_ = sut.Bind(lookupType(pkg, "Writer"), types.NewPointer(lookupType(pkg, "NetSink")))
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "\thandler := components.NewHandler(netSink)\n")
+}
+
+func TestWriteBuilderPassesAutoBoundProvider(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+	sut.AutoBind(true)
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "\thandler := components.NewHandler(fileSink)\n")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+// addQualifiedSinks adds the constructors in autoBindTestSrc and NewNetSink to
+// container with NewFileSink and NewNetSink providing the qualified
+// components file and net and with NewHandler requiring net.
+func addQualifiedSinks(t *testing.T, container *Container) *types.Package {
+	src := autoBindTestSrc + "\nfunc NewNetSink() *NetSink { return nil }\n"
+	pkg, _ := loadTestPackage(t, testComponentsPath, src)
+	addConstructors(t, container, pkg)
+	scope := pkg.Scope()
+	require.NoError(t, container.Name(scope.Lookup("NewFileSink"), "file"))
+	require.NoError(t, container.Name(scope.Lookup("NewNetSink"), "net"))
+	require.NoError(t, container.NameParam(scope.Lookup("NewHandler"), "w", "net"))
+	return pkg
+}
+
+func TestWriteBuilderPassesBoundQualifiedProvider(t *testing.T) {
+	sut := &Container{}
+	pkg := addQualifiedSinks(t, sut)
+	require.NoError(t, sut.Bind(lookupType(pkg, "Writer"), types.NewPointer(lookupType(pkg, "NetSink"))))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "\thandler := components.NewHandler(netNetSink)\n")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestContainerAutoBindMatchesQualifier(t *testing.T) {
+	sut := &Container{}
+	sut.AutoBind(true)
+	pkg := addQualifiedSinks(t, sut)
+	file, _ := sut.NodeFor(pkg.Scope().Lookup("NewFileSink"))
+	net, _ := sut.NodeFor(pkg.Scope().Lookup("NewNetSink"))
+	handler, _ := sut.NodeFor(pkg.Scope().Lookup("NewHandler"))
+
+	errs := sut.Validate()
+
+	assert.Empty(t, errs)
+	assert.True(t, sut.HasEdgeFromTo(net, handler))
+	assert.False(t, sut.HasEdgeFromTo(file, handler))
+}
+
+func TestContainerAutoBindIgnoresQualifiedProvidersOfUnqualifiedInterface(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, bindTestSrc)
+	sut := &Container{}
+	sut.AutoBind(true)
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.Name(pkg.Scope().Lookup("NewFileSink"), "file"))
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 1)
+	missing := errs[0].(*MissingDependencyError)
+	assert.Equal(t, lookupType(pkg, "Writer"), missing.Type)
+}
+
+// autoBindChainSrc returns the source of a package with a chain of n
+// constructors in which each constructor requires the interface of the type
+// provided by the one before it.
+func autoBindChainSrc(n int) string {
+	var src strings.Builder
+	src.WriteString("package components\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&src, "\ntype Link%d interface{ Link%d() }\n", i, i)
+		fmt.Fprintf(&src, "\ntype Impl%d struct{}\n", i)
+		fmt.Fprintf(&src, "\nfunc (*Impl%d) Link%d() {}\n", i, i)
+		if i == 0 {
+			src.WriteString("\nfunc NewImpl0() *Impl0 { return nil }\n")
+		} else {
+			fmt.Fprintf(&src, "\nfunc NewImpl%d(prev Link%d) *Impl%d { return nil }\n", i, i-1, i)
+		}
+	}
+	return src.String()
+}
+
+func TestWriteBuilderOfLongAutoBindChainIsFast(t *testing.T) {
+	const length = 600
+	pkg, _ := loadTestPackage(t, testComponentsPath, autoBindChainSrc(length))
+	sut := &Container{}
+	sut.AutoBind(true)
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddRoot("Last", types.NewPointer(lookupType(pkg, fmt.Sprintf("Impl%d", length-1)))))
+
+	start := time.Now()
+	errs := sut.Validate()
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+	elapsed := time.Since(start)
+
+	assert.Empty(t, errs)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "\timpl1 := components.NewImpl1(impl0)\n")
+	assert.True(t, elapsed < 5*time.Second, "took %v for a chain of %d constructors", elapsed, length)
+}
@@ -0,0 +1,256 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const (
+	// DefaultPackageName is the package name used for a builder when
+	// BuilderOptions does not specify one.
+	DefaultPackageName = "main"
+
+	// DefaultFuncName is the builder function name used when BuilderOptions
+	// does not specify one.
+	DefaultFuncName = "buildRoot"
+)
+
+// BuilderOptions control the source file written by Container.WriteBuilder.
+type BuilderOptions struct {
+	// PackageName is the name in the package clause of the source file.
+	PackageName string
+
+	// PackagePath is the import path of the package that will hold the source
+	// file. Functions and types from this package are not qualified.
+	PackagePath string
+
+	// FuncName is the name of the builder function.
+	FuncName string
+}
+
+// WriteBuilder writes a gofmt'ed Go source file to w that holds a builder
+// function for the Container. If the Container has named roots then the source
+// file holds a builder function for each of them, named "build" followed by the
+// name of the root, after the builder function for the root of the Container,
+// if any. Each builder function calls the function for each node from which its
+// root node can be reached, in an order that calls the provider of each
+// component before any function that requires that component, and then returns
+// the root component. If any of the called functions can return an error then
+// the builder function also returns an error: the first non-nil error, wrapped
+// with the name of the function that returned it. If any of the called
+// functions return a cleanup function then the builder function also returns a
+// cleanup function that calls each of them in the reverse of the order they
+// were returned. When the builder function returns an error it first calls the
+// cleanup functions returned so far.
+//
+// WriteBuilder returns ErrNoRoot if the Container does not have any roots, an
+// AmbiguousRootError if its root is ambiguous, an AmbiguousProviderError if a
+// required component has more than one provider and a CycleError if the nodes
+// cannot be ordered. These errors name the entry point of the builder function
+// for which they occurred if the Container has named roots. WriteBuilder
+// returns ErrDuplicateFuncName if the name of a builder function for a named
+// root is also opts.FuncName. A Container that is not complete produces a
+// source file that fails to compile with an error that names each missing
+// component.
+func (c *Container) WriteBuilder(w io.Writer, opts BuilderOptions) error {
+	if err := c.ambiguousRoot(); err != nil {
+		return err
+	}
+	roots := c.allRoots()
+	if len(roots) == 0 {
+		return ErrNoRoot
+	}
+	if opts.PackageName == "" {
+		opts.PackageName = DefaultPackageName
+	}
+	if opts.FuncName == "" {
+		opts.FuncName = DefaultFuncName
+	}
+	names := make(map[string]bool)
+	for _, root := range roots {
+		name := builderFuncName(root, opts)
+		if names[name] {
+			return ErrDuplicateFuncName
+		}
+		names[name] = true
+	}
+
+	orders := make([][]commonNode, len(roots))
+	for i, root := range roots {
+		order, err := c.buildOrder(root)
+		if err != nil {
+			return c.withEntryPoint(err, root)
+		}
+		orders[i] = order
+	}
+
+	// The first pass discovers the imported packages so that the variable
+	// names from the second pass do not shadow them.
+	imports := newImportSet(opts.PackagePath)
+	for i, root := range roots {
+		c.generateBuilder(root, orders[i], imports, nil, builderFuncName(root, opts))
+	}
+	gens := make([]*genContext, len(roots))
+	for i, root := range roots {
+		gens[i] = c.generateBuilder(root, orders[i], imports, imports.Names(), builderFuncName(root, opts))
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("// Code generated by dibuilder. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buffer, "package %s\n\n", opts.PackageName)
+	if groups := imports.Specs(); len(groups) > 0 {
+		buffer.WriteString("import (\n")
+		for i, group := range groups {
+			if i > 0 {
+				buffer.WriteString("\n")
+			}
+			for _, spec := range group {
+				fmt.Fprintf(&buffer, "%s\n", spec)
+			}
+		}
+		buffer.WriteString(")\n\n")
+	}
+	for i, gen := range gens {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.Write(gen.out.Bytes())
+	}
+
+	src, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(src)
+	return err
+}
+
+// builderFuncName returns the name of the builder function for root.
+func builderFuncName(root *rootNode, opts BuilderOptions) string {
+	if root.name == "" {
+		return opts.FuncName
+	}
+	return "build" + root.name
+}
+
+// withEntryPoint sets the entry point of an error from buildOrder to that of
+// root if the Container has named roots.
+func (c *Container) withEntryPoint(err error, root *rootNode) error {
+	if len(c.namedRoots) == 0 {
+		return err
+	}
+
+	switch err := err.(type) {
+	case *CycleError:
+		err.EntryPoints = []string{root.entryPoint()}
+	case *AmbiguousProviderError:
+		err.EntryPoints = []string{root.entryPoint()}
+	}
+	return err
+}
+
+// generateBuilder generates the declaration of the builder function for root
+// from the ordered nodes. No variable is given any of the reserved names.
+func (c *Container) generateBuilder(root *rootNode, order []commonNode, imports *importSet, reserved []string, funcName string) *genContext {
+	gen := newGenContext(typeutil.MakeHasher(), imports.Qualifier)
+	gen.resolve = c.resolve
+	gen.root = root.root
+	gen.namer.Reserve("err")
+	for _, name := range reserved {
+		gen.namer.Reserve(name)
+	}
+	cleanups := 0
+	for _, node := range order {
+		for _, typ := range node.requires() {
+			gen.require(typ)
+		}
+		if node, ok := node.(errorReturner); ok && node.returnsError() {
+			gen.returnsErr = true
+		}
+		if node, ok := node.(cleanupReturner); ok && node.returnsCleanup() {
+			gen.returnsCleanup = true
+			gen.namer.Reserve(cleanupName(cleanups))
+			cleanups++
+		}
+	}
+
+	gen.printf("// %s builds the components of the application and returns its root.\n", funcName)
+	if gen.returnsCleanup {
+		gen.printf("// The returned cleanup function releases the components in the reverse\n")
+		gen.printf("// of the order they were built.\n")
+	}
+	if gen.returnsErr {
+		gen.printf("// It returns the first error from the constructors of the components.\n")
+	}
+	gen.printf("func %s() %s {\n", funcName, gen.resultsString())
+	for _, node := range order {
+		node.Generate(gen)
+	}
+	gen.printf("}\n")
+
+	return gen
+}
+
+// buildOrder returns the nodes from which root can be reached, including root
+// itself, ordered so that each node comes after the nodes that provide its
+// requirements. buildOrder returns a CycleError if no such order exists.
+func (c *Container) buildOrder(root commonNode) ([]commonNode, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	var order []commonNode
+	var stack []commonNode
+	state := make(map[int]int)
+
+	var visit func(node commonNode) error
+	visit = func(node commonNode) error {
+		switch state[node.ID()] {
+		case visiting:
+			// the nodes on the stack from node onwards form the cycle
+			for i := range stack {
+				if stack[i] == node {
+					return c.newCycleError(stack[i:])
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		for _, require := range node.requires() {
+			if len(c.providersFor(require)) > 1 {
+				return c.newAmbiguousProviderError(require, c.requirersOf(require))
+			}
+		}
+
+		state[node.ID()] = visiting
+		stack = append(stack, node)
+		for _, provider := range c.To(node) {
+			if err := visit(provider.(commonNode)); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[node.ID()] = visited
+
+		order = append(order, node)
+		return nil
+	}
+
+	if err := visit(root); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
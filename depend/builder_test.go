@@ -0,0 +1,394 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const builderTestSrc = `package components
+
+type Config struct{}
+
+type Store struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+func NewServer(store *Store, config Config) *Server { return nil }
+
+func NewStore(config Config) *Store { return nil }
+
+func NewConfig() Config { return Config{} }
+`
+
+func TestWriteBuilderWritesOrderedBuilder(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, `// Code generated by dibuilder. DO NOT EDIT.
+
+package main
+
+import (
+	"github.com/sbosnick/myproject/components"
+)
+
+// buildRoot builds the components of the application and returns its root.
+func buildRoot() *components.Server {
+	config := components.NewConfig()
+	store := components.NewStore(config)
+	server := components.NewServer(store, config)
+	return server
+}
+`, out.String())
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestWriteBuilderUsesOptions(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{
+		PackageName: "components",
+		PackagePath: testComponentsPath,
+		FuncName:    "buildServer",
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "package components\n")
+	assert.NotContains(t, out.String(), "import")
+	assert.Contains(t, out.String(), "func buildServer() *Server {\n")
+	assert.Contains(t, out.String(), "\tstore := NewStore(config)\n")
+}
+
+func TestWriteBuilderAvoidsShadowingImports(t *testing.T) {
+	src := `package config
+
+type Config struct{}
+
+type App struct{}
+
+func (a App) Run() {}
+
+func NewConfig() *Config { return nil }
+
+func NewApp(config *Config) App { return App{} }
+`
+	pkg, _ := loadTestPackage(t, "github.com/sbosnick/myproject/config", src)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "\tconfig_A := config.NewConfig()\n")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestWriteBuilderWithoutRootIsError(t *testing.T) {
+	sut := &Container{}
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	assert.Equal(t, ErrNoRoot, err)
+}
+
+func TestWriteBuilderWithCycleIsError(t *testing.T) {
+	src := `package components
+
+type A struct{}
+
+type B struct{}
+
+func (a *A) Run() {}
+
+func NewA(b *B) *A { return nil }
+
+func NewB(a *A) *B { return nil }
+`
+	pkg, _ := loadTestPackage(t, testComponentsPath, src)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.IsType(t, &CycleError{}, err)
+	assert.Len(t, err.(*CycleError).Cycle, 2)
+}
+
+func TestWriteBuilderForIncompleteContainerFailsToCompile(t *testing.T) {
+	src := `package components
+
+type Config struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+func NewServer(config Config) *Server { return nil }
+`
+	pkg, _ := loadTestPackage(t, testComponentsPath, src)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	typeErr := typecheckGenerated(out.String(), pkg)
+	require.Error(t, typeErr)
+	assert.Contains(t, typeErr.Error(), "missingProviderFor_config")
+}
+
+func TestWriteBuilderWithAmbiguousProviderIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, ambiguousTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	assert.IsType(t, &AmbiguousProviderError{}, err)
+}
+
+func TestWriteBuilderCallsPreferredProvider(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, ambiguousTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	_ = sut.Prefer(pkg.Scope().Lookup("NewOtherLogger"))
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "\tlogger := components.NewOtherLogger()\n")
+	assert.NotContains(t, out.String(), "components.NewLogger()")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+const errorTestSrc = `package components
+
+type Config struct{}
+
+type Store struct{}
+
+type Server struct{}
+
+func (s Server) Run() {}
+
+func NewServer(store *Store) (Server, error) { return Server{}, nil }
+
+func NewStore(config Config) (*Store, error) { return nil, nil }
+
+func NewConfig() Config { return Config{} }
+`
+
+func TestWriteBuilderReturnsErrorsFromConstructors(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, errorTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, `// Code generated by dibuilder. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/sbosnick/myproject/components"
+)
+
+// buildRoot builds the components of the application and returns its root.
+// It returns the first error from the constructors of the components.
+func buildRoot() (components.Server, error) {
+	config := components.NewConfig()
+	store, err := components.NewStore(config)
+	if err != nil {
+		return components.Server{}, fmt.Errorf("components.NewStore: %w", err)
+	}
+	server, err := components.NewServer(store)
+	if err != nil {
+		return components.Server{}, fmt.Errorf("components.NewServer: %w", err)
+	}
+	return server, nil
+}
+`, out.String())
+	fmtPkg := types.NewPackage("fmt", "fmt")
+	errType := types.Universe.Lookup("error").Type()
+	errorf := types.NewFunc(token.NoPos, fmtPkg, "Errorf", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(
+			types.NewParam(token.NoPos, fmtPkg, "format", types.Typ[types.String]),
+			types.NewParam(token.NoPos, fmtPkg, "a", types.NewSlice(types.NewInterfaceType(nil, nil)))),
+		types.NewTuple(types.NewParam(token.NoPos, fmtPkg, "", errType)), true))
+	fmtPkg.Scope().Insert(errorf)
+	fmtPkg.MarkComplete()
+	assert.NoError(t, typecheckGenerated(out.String(), pkg, fmtPkg))
+}
+
+const cleanupTestSrc = `package components
+
+type DB struct{}
+
+type Listener struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+func NewServer(db *DB, listener *Listener) (*Server, error) { return nil, nil }
+
+func NewDB() (*DB, func(), error) { return nil, nil, nil }
+
+func NewListener(db *DB) (*Listener, func()) { return nil, nil }
+`
+
+func TestWriteBuilderChainsCleanups(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, cleanupTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `// buildRoot builds the components of the application and returns its root.
+// The returned cleanup function releases the components in the reverse
+// of the order they were built.
+// It returns the first error from the constructors of the components.
+func buildRoot() (*components.Server, func(), error) {
+	dB, cleanup, err := components.NewDB()
+	if err != nil {
+		return nil, nil, fmt.Errorf("components.NewDB: %w", err)
+	}
+	listener, cleanup2 := components.NewListener(dB)
+	server, err := components.NewServer(dB, listener)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, fmt.Errorf("components.NewServer: %w", err)
+	}
+	return server, func() {
+		cleanup2()
+		cleanup()
+	}, nil
+}
+`)
+}
+
+func TestWriteBuilderReturnsCleanupWithoutError(t *testing.T) {
+	src := `package components
+
+type Listener struct{}
+
+func (l *Listener) Run() {}
+
+func NewListener() (*Listener, func()) { return nil, nil }
+`
+	pkg, _ := loadTestPackage(t, testComponentsPath, src)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "func buildRoot() (*components.Listener, func()) {\n")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+const namedRootsTestSrc = `package components
+
+type Config struct{}
+
+type Store struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+type Worker struct{}
+
+func (w *Worker) Run() {}
+
+func NewServer(store *Store, config Config) *Server { return nil }
+
+func NewWorker(config Config) *Worker { return nil }
+
+func NewStore(config Config) *Store { return nil }
+
+func NewConfig() Config { return Config{} }
+`
+
+func TestWriteBuilderWritesBuilderForEachNamedRoot(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, namedRootsTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddRoot("APIServer", types.NewPointer(pkg.Scope().Lookup("Server").Type())))
+	require.NoError(t, sut.AddRoot("Worker", types.NewPointer(pkg.Scope().Lookup("Worker").Type())))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, `// Code generated by dibuilder. DO NOT EDIT.
+
+package main
+
+import (
+	"github.com/sbosnick/myproject/components"
+)
+
+// buildAPIServer builds the components of the application and returns its root.
+func buildAPIServer() *components.Server {
+	config := components.NewConfig()
+	store := components.NewStore(config)
+	server := components.NewServer(store, config)
+	return server
+}
+
+// buildWorker builds the components of the application and returns its root.
+func buildWorker() *components.Worker {
+	config := components.NewConfig()
+	worker := components.NewWorker(config)
+	return worker
+}
+`, out.String())
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestWriteBuilderWithDuplicateFuncNameIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, namedRootsTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.SetRoot(types.NewPointer(pkg.Scope().Lookup("Server").Type())))
+	require.NoError(t, sut.AddRoot("Worker", types.NewPointer(pkg.Scope().Lookup("Worker").Type())))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{FuncName: "buildWorker"})
+
+	assert.Equal(t, ErrDuplicateFuncName, err)
+}
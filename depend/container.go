@@ -6,7 +6,9 @@
 package depend
 
 import (
+	"go/token"
 	"go/types"
+	"sort"
 
 	"golang.org/x/tools/go/types/typeutil"
 
@@ -22,6 +24,14 @@ type Container struct {
 	nodes       []commonNode
 	providedBy  *typeNodeMap
 	requiredBy  *typeNodeMap
+	preferred   map[int]bool
+	bindings    typeutil.Map
+	keys        *typeutil.Map
+	autoBind    bool
+	rootMethods []RootMethod
+	candidates  []rootCandidate
+	explicit    bool
+	namedRoots  []*rootNode
 }
 
 // Has returns whether a node exists within the Container.
@@ -46,17 +56,34 @@ func (c *Container) Nodes() []graph.Node {
 }
 
 // From returns all nodes that can be reached directly from the given node.
+// These are the requirers, found through the index of requirements, of each
+// component that the node provides to them, in the order of their IDs.
 func (c *Container) From(node graph.Node) []graph.Node {
-	var nodes []graph.Node
+	provider, ok := node.(commonNode)
+	if !ok {
+		return nil
+	}
 
-	if node, ok := node.(commonNode); ok {
-		for _, provide := range node.provides() {
-			for _, requirer := range c.requiredBy.Nodes(provide) {
-				nodes = append(nodes, requirer)
+	provided := provider.provides()
+	seen := make(map[int]bool)
+	var requirers []commonNode
+	for _, typ := range c.requiredBy.Types() {
+		if !c.satisfiesWith(provider, provided, typ) {
+			continue
+		}
+		for _, requirer := range c.requiredBy.Nodes(typ) {
+			if !seen[requirer.ID()] {
+				seen[requirer.ID()] = true
+				requirers = append(requirers, requirer)
 			}
 		}
 	}
 
+	sort.Slice(requirers, func(i, j int) bool { return requirers[i].ID() < requirers[j].ID() })
+	nodes := make([]graph.Node, 0, len(requirers))
+	for _, requirer := range requirers {
+		nodes = append(nodes, requirer)
+	}
 	return nodes
 }
 
@@ -69,7 +96,7 @@ func (c *Container) To(node graph.Node) []graph.Node {
 
 	if node, ok := node.(commonNode); ok {
 		for _, require := range node.requires() {
-			providers := c.providedBy.Nodes(require)
+			providers := c.providersFor(require)
 			if len(providers) == 0 {
 				missing = true
 				continue
@@ -96,12 +123,8 @@ func (c *Container) HasEdgeBetween(x graph.Node, y graph.Node) bool {
 // HasEdgeFromTo returns whether an edge exists in the Container from u to v.
 func (c *Container) HasEdgeFromTo(u graph.Node, v graph.Node) bool {
 	if u, ok := u.(commonNode); ok {
-		for _, provide := range u.provides() {
-			for _, provider := range c.requiredBy.Nodes(provide) {
-				if provider == v {
-					return true
-				}
-			}
+		if v, ok := v.(commonNode); ok {
+			return len(c.edgeTypes(u, v)) > 0
 		}
 	}
 
@@ -118,6 +141,125 @@ func (c *Container) Edge(u graph.Node, v graph.Node) graph.Edge {
 	return nil
 }
 
+// edgeTypes returns the components provided by u that are required by v. These
+// are the components that the edge from u to v carries.
+func (c *Container) edgeTypes(u commonNode, v commonNode) []types.Type {
+	var result []types.Type
+
+	for _, require := range v.requires() {
+		if c.satisfies(u, require) {
+			result = append(result, require)
+		}
+	}
+
+	return result
+}
+
+// satisfies returns whether node provides the component required as typ.
+func (c *Container) satisfies(node commonNode, typ types.Type) bool {
+	return c.satisfiesWith(node, node.provides(), typ)
+}
+
+// satisfiesWith returns whether node, whose provided components are provided,
+// provides the component required as typ.
+func (c *Container) satisfiesWith(node commonNode, provided []types.Type, typ types.Type) bool {
+	keys := c.keysFor(typ)
+	for _, provide := range provided {
+		if _, found := findType(keys, provide); !found {
+			continue
+		}
+
+		providers := c.providersFor(typ)
+		if len(providers) == 0 {
+			// only the missingNode provides a type without providers
+			return true
+		}
+		for _, provider := range providers {
+			if provider.ID() == node.ID() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// providersFor returns the nodes that provide the component required as typ.
+// These are the nodes that provide the types from keysFor. If more than one
+// node provides the component and some of those nodes are preferred then only
+// the preferred nodes are returned.
+func (c *Container) providersFor(typ types.Type) []commonNode {
+	var providers []commonNode
+	for _, key := range c.keysFor(typ) {
+		providers = append(providers, c.providedBy.Nodes(key)...)
+	}
+	if len(providers) < 2 {
+		return providers
+	}
+
+	var preferred []commonNode
+	for _, provider := range providers {
+		if c.preferred[provider.ID()] {
+			preferred = append(preferred, provider)
+		}
+	}
+	if len(preferred) == 0 {
+		return providers
+	}
+
+	return preferred
+}
+
+// Prefer marks the node created from decl as the preferred provider of its
+// components. When more than one node provides a component that some node
+// requires, the requirement is satisfied by the preferred provider. decl must
+// have been added to the Container, otherwise Prefer returns ErrNotInContainer.
+func (c *Container) Prefer(decl types.Object) error {
+	node := c.nodeFor(decl)
+	if node == nil {
+		return ErrNotInContainer
+	}
+
+	if c.preferred == nil {
+		c.preferred = make(map[int]bool)
+	}
+	c.preferred[node.ID()] = true
+	return nil
+}
+
+// NodeFor returns the node created from decl. It returns ErrNotInContainer if
+// decl has not been added to the Container.
+func (c *Container) NodeFor(decl types.Object) (graph.Node, error) {
+	node := c.nodeFor(decl)
+	if node == nil {
+		return nil, ErrNotInContainer
+	}
+
+	return node, nil
+}
+
+// Provides returns the components provided by the node created from decl. It
+// returns ErrNotInContainer if decl has not been added to the Container.
+func (c *Container) Provides(decl types.Object) ([]types.Type, error) {
+	node := c.nodeFor(decl)
+	if node == nil {
+		return nil, ErrNotInContainer
+	}
+
+	return node.provides(), nil
+}
+
+// nodeFor returns the node created from decl or nil if there is no such node.
+func (c *Container) nodeFor(decl types.Object) commonNode {
+	for _, node := range c.nodes {
+		if obj := node.object(); obj != nil && obj == decl {
+			return node
+		}
+	}
+
+	return nil
+}
+
 // setRoot sets the root type for the Container. A Container for which a root
 // type has been set has a root node.
 func (c *Container) setRoot(root types.Type) error {
@@ -130,9 +272,99 @@ func (c *Container) setRoot(root types.Type) error {
 	return nil
 }
 
+// SetRoot sets the root type of the Container explicitly. The explicit root
+// replaces the root auto-detected by AddFunc, if any, and suppresses the
+// auto-detection of root types for the Container. SetRoot returns
+// ErrRootAlreadySet if it has already been called.
+func (c *Container) SetRoot(root types.Type) error {
+	if c.explicit {
+		return ErrRootAlreadySet
+	}
+	c.explicit = true
+
+	if c.rootnode == nil {
+		return c.setRoot(root)
+	}
+
+	c.retargetRoot(c.rootnode, root)
+	return nil
+}
+
+// AddRoot adds a named root to the Container. Each named root is a separate
+// entry point into the Container: WriteBuilder writes a builder function for
+// each of them that builds only the components from which its root can be
+// reached. name must be a valid Go identifier other than DefaultRootName, the
+// name of the entry point for the root that is not named. Adding a named root
+// suppresses the auto-detection of root types for the Container and replaces
+// the root auto-detected by AddFunc, if any. AddRoot returns ErrInvalidRootName
+// for an invalid name and ErrRootAlreadySet if name is already the name of a
+// root.
+func (c *Container) AddRoot(name string, root types.Type) error {
+	if !token.IsIdentifier(name) || name == DefaultRootName {
+		return ErrInvalidRootName
+	}
+	for _, named := range c.namedRoots {
+		if named.name == name {
+			return ErrRootAlreadySet
+		}
+	}
+
+	if c.rootnode != nil && !c.explicit {
+		// the auto-detected root becomes the named root
+		named := c.rootnode
+		c.rootnode = nil
+		named.name = name
+		c.retargetRoot(named, root)
+		c.namedRoots = append(c.namedRoots, named)
+		return nil
+	}
+
+	named := newRootNode(c, c.nextID(), root)
+	named.name = name
+	c.namedRoots = append(c.namedRoots, named)
+	c.addNode(named)
+	return nil
+}
+
+// Roots returns the root node of the Container, if any, followed by its named
+// root nodes in the order they were added. It returns an AmbiguousRootError if
+// more than one root type has been auto-detected and neither SetRoot nor
+// AddRoot has been called.
+func (c *Container) Roots() ([]graph.Node, error) {
+	if err := c.ambiguousRoot(); err != nil {
+		return nil, err
+	}
+
+	var nodes []graph.Node
+	for _, root := range c.allRoots() {
+		nodes = append(nodes, root)
+	}
+	return nodes, nil
+}
+
+// allRoots returns the root node, if any, followed by the named root nodes.
+func (c *Container) allRoots() []*rootNode {
+	var roots []*rootNode
+	if c.rootnode != nil {
+		roots = append(roots, c.rootnode)
+	}
+	return append(roots, c.namedRoots...)
+}
+
+// retargetRoot changes the type required by the root node to root.
+func (c *Container) retargetRoot(node *rootNode, root types.Type) {
+	c.requiredBy.RemoveNode(node.root, node)
+	node.root = root
+	c.requiredBy.AddNode(root, node)
+}
+
 // Root returns the root node of the container or ErrNoRoot is a root
-// has not been set.
+// has not been set. It returns an AmbiguousRootError if more than one
+// root type has been auto-detected and SetRoot has not been called.
 func (c *Container) Root() (graph.Node, error) {
+	if err := c.ambiguousRoot(); err != nil {
+		return nil, err
+	}
 	if c.rootnode != nil {
 		return c.rootnode, nil
 	}
@@ -148,14 +380,17 @@ func (c *Container) Root() (graph.Node, error) {
 // are required to be satisfied by components in the Container for the Container
 // to be complete. function can have an error return type as its last return type.
 //
-// AddFunc will auto-detect root types that are provided by function. A root type
-// for this purpose is a types.Type whose method set includes a nullary method named
-// "Run". AddFunc will return an error if it auto-detects a second root type for the
-// Container.
+// Unless SetRoot has been called, AddFunc will auto-detect root types that are
+// provided by function. A root type for this purpose is a types.Type whose method
+// set includes a method that matches one of the root methods of the Container
+// (see SetRootMethods). If AddFunc auto-detects more than one root type for the
+// Container then Root, Validate and WriteBuilder report an AmbiguousRootError
+// until SetRoot selects one of them or AddRoot adds a named root.
 //
 // AddFunc will return an InvalidFuncError for a function with an error return type
-// in any position except the last. It will also return an InvalidFuncError if a
-// method is passed in as function.
+// in any position except the last or that provides the same component more
+// than once. It will also return an InvalidFuncError if a method or a generic
+// function is passed in as function.
 func (c *Container) AddFunc(function *types.Func) error {
 	// create a new node
 	node, err := newFuncNode(c, c.nextID(), function)
@@ -165,20 +400,117 @@ func (c *Container) AddFunc(function *types.Func) error {
 
 	c.addNode(node)
 
-	root, err := detectRootType(node.provides())
+	return c.addCandidates(function, detectRootTypes(node.provides(), c.getRootMethods()))
+}
+
+// AddStruct adds a node to the Container that provides instances of the named
+// struct type typ and of the pointer to typ by populating its fields. The
+// fields of typ tagged with `dibuilder:"inject"`, or all of its exported fields
+// if no field is so tagged, are required to be satisfied by components in the
+// Container for the Container to be complete.
+//
+// Unless SetRoot or AddRoot has been called, AddStruct will auto-detect a root
+// type as AddFunc does. At most one of typ and the pointer to typ becomes a
+// candidate root, with typ preferred.
+//
+// AddStruct will return an InvalidDeclError if typ is not an exported struct
+// type, is a generic type, has a tagged field that is not exported or injects
+// a field of an unexported type.
+func (c *Container) AddStruct(typ *types.Named) error {
+	node, err := newStructNode(c, c.nextID(), typ)
 	if err != nil {
 		return err
 	}
-	if root != nil {
-		err = c.setRoot(root)
-		if err != nil {
-			return err
-		}
+
+	c.addNode(node)
+
+	roots := detectRootTypes(node.provides(), c.getRootMethods())
+	if len(roots) > 1 {
+		roots = roots[:1]
+	}
+	return c.addCandidates(typ.Obj(), roots)
+}
+
+// AddVar adds a node to the Container that provides the type of the
+// package-level variable value by referring to it. The node has no
+// requirements. Unless SetRoot or AddRoot has been called, AddVar will
+// auto-detect a root type as AddFunc does.
+//
+// AddVar will return an InvalidDeclError if value is not an exported
+// package-level variable.
+func (c *Container) AddVar(value *types.Var) error {
+	return c.addValue(value)
+}
+
+// AddConst adds a node to the Container that provides the type of the
+// package-level constant value, as AddVar does for a variable. AddConst will
+// return an InvalidDeclError if value is not exported or is an untyped
+// constant, such as "const DefaultPort = 8080", rather than a typed constant,
+// such as "const DefaultPort Port = 8080".
+func (c *Container) AddConst(value *types.Const) error {
+	return c.addValue(value)
+}
+
+func (c *Container) addValue(value types.Object) error {
+	node, err := newValueNode(c, c.nextID(), value)
+	if err != nil {
+		return err
+	}
+
+	c.addNode(node)
+
+	return c.addCandidates(value, detectRootTypes(node.provides(), c.getRootMethods()))
+}
+
+// addCandidates records the root types provided by decl as candidate roots of
+// the Container unless SetRoot or AddRoot has been called. The first candidate
+// becomes the root.
+func (c *Container) addCandidates(decl types.Object, roots []types.Type) error {
+	if c.explicit || len(c.namedRoots) > 0 {
+		return nil
+	}
+	for _, root := range roots {
+		c.candidates = append(c.candidates, rootCandidate{root: root, decl: decl})
+	}
+	if c.rootnode == nil && len(c.candidates) > 0 {
+		return c.setRoot(c.candidates[0].root)
 	}
 
 	return nil
 }
 
+// ambiguousRoot returns an AmbiguousRootError if more than one root type has
+// been auto-detected and neither SetRoot nor AddRoot has been called. Otherwise
+// it returns nil.
+func (c *Container) ambiguousRoot() *AmbiguousRootError {
+	if c.explicit || len(c.namedRoots) > 0 || len(c.candidates) < 2 {
+		return nil
+	}
+
+	err := &AmbiguousRootError{}
+	for _, candidate := range c.candidates {
+		err.Types = append(err.Types, candidate.root)
+		err.Constructors = append(err.Constructors, candidate.decl)
+	}
+	return err
+}
+
+// SetRootMethods sets the methods that mark a type provided by a function
+// passed to AddFunc as a root type. It affects only functions added after it
+// is called. A Container uses DefaultRootMethods if SetRootMethods is not
+// called.
+func (c *Container) SetRootMethods(methods ...RootMethod) {
+	c.rootMethods = methods
+}
+
+func (c *Container) getRootMethods() []RootMethod {
+	if c.rootMethods == nil {
+		return DefaultRootMethods
+	}
+
+	return c.rootMethods
+}
+
 func (c *Container) ensureMissingNode() {
 	if c.missingNode == nil {
 		c.missingNode = newMissingNode(c, c.nextID())
@@ -212,4 +544,5 @@ func (c *Container) addNode(newNode commonNode) {
 	for _, typ := range newNode.requires() {
 		c.requiredBy.AddNode(typ, newNode)
 	}
+	c.resetKeys()
 }
@@ -5,10 +5,13 @@
 package depend
 
 import (
+	"errors"
+	"go/types"
 	"testing"
 
 	"github.com/cheekybits/is"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockNode struct{}
@@ -37,8 +40,7 @@ func TestContainerAddFuncDetectsAndAddsRootTypeProvidedByFunc(t *testing.T) {
 	is.OK(root)
 }
 
-func TestContainerAddFuncIsErrorWithSecondRootProvider(t *testing.T) {
-	is := is.New(t)
+func TestContainerWithSecondRootProviderHasAmbiguousRoot(t *testing.T) {
 	roottype1 := makeRunnableType("MyFirstType")
 	roottype2 := makeRunnableType("MySecondType")
 	function1 := makeFunc(nil, roottype1, false)
@@ -46,7 +48,174 @@ func TestContainerAddFuncIsErrorWithSecondRootProvider(t *testing.T) {
 
 	sut := &Container{}
 	sut.AddFunc(function1)
-	err := sut.AddFunc(function2)
+	addErr := sut.AddFunc(function2)
+	_, err := sut.Root()
+
+	assert.NoError(t, addErr)
+	require.IsType(t, &AmbiguousRootError{}, err)
+	assert.True(t, errors.Is(err, ErrAmbiguousRootDetected))
+	rootErr := err.(*AmbiguousRootError)
+	assert.Equal(t, []types.Type{roottype1, roottype2}, rootErr.Types)
+	assert.Equal(t, []types.Object{function1, function2}, rootErr.Constructors)
+}
+
+func TestContainerSetRootOverridesAutoDetectedRoot(t *testing.T) {
+	roottype1 := makeRunnableType("MyFirstType")
+	roottype2 := makeRunnableType("MySecondType")
+
+	sut := &Container{}
+	sut.AddFunc(makeFunc(nil, roottype1, false))
+	sut.AddFunc(makeFunc(nil, roottype2, false))
+	err := sut.SetRoot(roottype2)
+	root, rootErr := sut.Root()
+
+	assert.NoError(t, err)
+	require.NoError(t, rootErr)
+	assert.Equal(t, roottype2, root.(*rootNode).root)
+	assert.Equal(t, []types.Type{roottype2}, sut.requiredKeys())
+}
+
+func TestContainerSetRootSuppressesAutoDetection(t *testing.T) {
+	roottype := makeRunnableType("MyRootType")
+	explicit := types.Typ[types.Int]
+
+	sut := &Container{}
+	err := sut.SetRoot(explicit)
+	sut.AddFunc(makeFunc(nil, roottype, false))
+	root, rootErr := sut.Root()
+
+	assert.NoError(t, err)
+	require.NoError(t, rootErr)
+	assert.Equal(t, explicit, root.(*rootNode).root)
+}
+
+func TestContainerSetRootTwiceIsError(t *testing.T) {
+	sut := &Container{}
+	_ = sut.SetRoot(types.Typ[types.Int])
+
+	err := sut.SetRoot(types.Typ[types.Bool])
+
+	assert.Equal(t, ErrRootAlreadySet, err)
+}
+
+const ambiguousTestSrc = `package components
+
+type Logger struct{}
+
+type App struct{}
+
+func (a *App) Run() {}
+
+func NewApp(logger *Logger) *App { return nil }
+
+func NewLogger() *Logger { return nil }
+
+func NewOtherLogger() *Logger { return nil }
+`
+
+func TestContainerToIncludesEveryAmbiguousProvider(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, ambiguousTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	app := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewApp").(*types.Func))
+
+	nodes := sut.To(app)
+
+	assert.Len(t, nodes, 2)
+}
+
+func TestContainerToIncludesOnlyPreferredProvider(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, ambiguousTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	preferred := pkg.Scope().Lookup("NewOtherLogger").(*types.Func)
+	other := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewLogger").(*types.Func))
+	app := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewApp").(*types.Func))
+
+	err := sut.Prefer(preferred)
+
+	require.NoError(t, err)
+	nodes := sut.To(app)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, findFuncNodeForFunction(sut.Nodes(), preferred), nodes[0])
+	assert.False(t, sut.HasEdgeFromTo(other, app))
+	assert.Empty(t, sut.From(other))
+}
+
+func TestContainerFromReturnsEachRequirerOnceInOrder(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	config := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewConfig").(*types.Func))
+	server := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewServer").(*types.Func))
+	store := findFuncNodeForFunction(sut.Nodes(), pkg.Scope().Lookup("NewStore").(*types.Func))
+
+	nodes := sut.From(config)
+
+	assert.Equal(t, []interface{}{server, store}, toInterfaces(nodes))
+}
+
+func TestContainerPreferOfUnknownDeclarationIsError(t *testing.T) {
+	sut := &Container{}
+
+	err := sut.Prefer(makeFunc(nil, nil, false))
+
+	assert.Equal(t, ErrNotInContainer, err)
+}
+
+func TestContainerAddRootReplacesAutoDetectedRoot(t *testing.T) {
+	roottype1 := makeRunnableType("MyFirstType")
+	roottype2 := makeRunnableType("MySecondType")
+
+	sut := &Container{}
+	sut.AddFunc(makeFunc(nil, roottype1, false))
+	sut.AddFunc(makeFunc(nil, roottype2, false))
+	err := sut.AddRoot("Second", roottype2)
+	roots, rootsErr := sut.Roots()
+	_, rootErr := sut.Root()
+
+	assert.NoError(t, err)
+	require.NoError(t, rootsErr)
+	require.Len(t, roots, 1)
+	assert.Equal(t, "Second", roots[0].(*rootNode).name)
+	assert.Equal(t, ErrNoRoot, rootErr)
+	assert.Equal(t, []types.Type{roottype2}, sut.requiredKeys())
+}
+
+func TestContainerRootsIncludesRootAndNamedRoots(t *testing.T) {
+	sut := &Container{}
+	_ = sut.SetRoot(types.Typ[types.Int])
+	_ = sut.AddRoot("Worker", types.Typ[types.Bool])
+
+	roots, err := sut.Roots()
+
+	require.NoError(t, err)
+	require.Len(t, roots, 2)
+	assert.Equal(t, types.Typ[types.Int], roots[0].(*rootNode).root)
+	assert.Equal(t, types.Typ[types.Bool], roots[1].(*rootNode).root)
+}
+
+func TestContainerAddRootWithInvalidNameIsError(t *testing.T) {
+	sut := &Container{}
+
+	err := sut.AddRoot("api-server", types.Typ[types.Int])
+
+	assert.Equal(t, ErrInvalidRootName, err)
+}
+
+func TestContainerAddRootWithDefaultRootNameIsError(t *testing.T) {
+	sut := &Container{}
+
+	err := sut.AddRoot(DefaultRootName, types.Typ[types.Int])
+
+	assert.Equal(t, ErrInvalidRootName, err)
+}
+
+func TestContainerAddRootWithDuplicateNameIsError(t *testing.T) {
+	sut := &Container{}
+	_ = sut.AddRoot("Worker", types.Typ[types.Int])
+
+	err := sut.AddRoot("Worker", types.Typ[types.Bool])
 
-	is.Err(err)
+	assert.Equal(t, ErrRootAlreadySet, err)
 }
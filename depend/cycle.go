@@ -0,0 +1,147 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+// Cycles returns a CycleError for each set of nodes in the Container whose
+// requirements form a cycle. Each strongly connected component of the
+// Container is reported once, with one cycle through that component. If the
+// Container has named roots, each CycleError names the entry points from
+// which the cycle can be reached.
+func (c *Container) Cycles() []Error {
+	var errs []Error
+
+	roots, paths := c.rootPaths()
+	for _, component := range c.stronglyConnected() {
+		if len(component) == 1 && !c.requiresItself(component[0]) {
+			continue
+		}
+
+		err := c.newCycleError(c.findCycle(component))
+		err.EntryPoints = c.entryPoints(roots, paths, component)
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// stronglyConnected returns the strongly connected components of the Container
+// using Tarjan's algorithm.
+func (c *Container) stronglyConnected() [][]commonNode {
+	var (
+		index      int
+		stack      []commonNode
+		components [][]commonNode
+		indexes    = make(map[int]int)
+		lowlinks   = make(map[int]int)
+		onStack    = make(map[int]bool)
+	)
+
+	var strongConnect func(node commonNode)
+	strongConnect = func(node commonNode) {
+		indexes[node.ID()] = index
+		lowlinks[node.ID()] = index
+		index++
+		stack = append(stack, node)
+		onStack[node.ID()] = true
+
+		for _, provider := range c.To(node) {
+			provider := provider.(commonNode)
+			if _, visited := indexes[provider.ID()]; !visited {
+				strongConnect(provider)
+				lowlinks[node.ID()] = minInt(lowlinks[node.ID()], lowlinks[provider.ID()])
+			} else if onStack[provider.ID()] {
+				lowlinks[node.ID()] = minInt(lowlinks[node.ID()], indexes[provider.ID()])
+			}
+		}
+
+		if lowlinks[node.ID()] == indexes[node.ID()] {
+			var component []commonNode
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top.ID()] = false
+				component = append(component, top)
+				if top == node {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	c.ensureMissingNode()
+	for _, node := range c.nodes {
+		if _, visited := indexes[node.ID()]; !visited {
+			strongConnect(node)
+		}
+	}
+
+	return components
+}
+
+// findCycle returns the shortest cycle through the first node of a strongly
+// connected component. Each node in the cycle requires a component provided by
+// the next node and the last node requires a component provided by the first.
+func (c *Container) findCycle(component []commonNode) []commonNode {
+	start := component[0]
+	inComponent := make(map[int]bool)
+	for _, node := range component {
+		inComponent[node.ID()] = true
+	}
+
+	parents := make(map[int]commonNode)
+	queue := []commonNode{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, provider := range c.To(node) {
+			provider := provider.(commonNode)
+			if provider == start {
+				cycle := []commonNode{node}
+				for node != start {
+					node = parents[node.ID()]
+					cycle = append(cycle, node)
+				}
+				reverseNodes(cycle)
+				return cycle
+			}
+			if _, seen := parents[provider.ID()]; seen || !inComponent[provider.ID()] {
+				continue
+			}
+			parents[provider.ID()] = node
+			queue = append(queue, provider)
+		}
+	}
+
+	return component
+}
+
+func (c *Container) requiresItself(node commonNode) bool {
+	return c.HasEdgeFromTo(node, node)
+}
+
+func (c *Container) newCycleError(cycle []commonNode) *CycleError {
+	err := &CycleError{}
+	for i, node := range cycle {
+		next := cycle[(i+1)%len(cycle)]
+		err.Cycle = append(err.Cycle, node.object())
+		err.Types = append(err.Types, c.edgeTypes(next, node)[0])
+	}
+	return err
+}
+
+func reverseNodes(nodes []commonNode) {
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cycleTestSrc = `package components
+
+type A struct{}
+
+type B struct{}
+
+type C struct{}
+
+type Self struct{}
+
+func NewA(b *B) *A { return nil }
+
+func NewB(c *C) *B { return nil }
+
+func NewC(a *A) *C { return nil }
+
+func NewSelf(self *Self) *Self { return nil }
+`
+
+func TestCyclesOfAcyclicContainerIsEmpty(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Cycles()
+
+	assert.Empty(t, errs)
+}
+
+func TestCyclesReportsEachCycle(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, cycleTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Cycles()
+
+	require.Len(t, errs, 2)
+	assert.Len(t, errs[0].(*CycleError).Cycle, 3)
+	assert.Len(t, errs[1].(*CycleError).Cycle, 1)
+}
+
+func TestCycleErrorRecordsTypesAlongCycle(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, cycleTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Cycles()
+
+	require.NotEmpty(t, errs)
+	err := errs[0].(*CycleError)
+	require.Len(t, err.Types, len(err.Cycle))
+	for i, obj := range err.Cycle {
+		next := err.Cycle[(i+1)%len(err.Cycle)]
+		assert.Equal(t, obj.Type().(*types.Signature).Params().At(0).Type(), err.Types[i])
+		assert.Equal(t, next.Type().(*types.Signature).Results().At(0).Type(), err.Types[i])
+	}
+}
+
+func TestCycleErrorWithPositionListsEachDeclaration(t *testing.T) {
+	pkg, fileset := loadTestPackage(t, testComponentsPath, cycleTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Cycles()
+
+	require.NotEmpty(t, errs)
+	result := errs[0].ErrorWithPosition(fileset)
+	assert.Contains(t, result, "components.NewA at src.go:11:6 requires *components.B")
+	assert.Contains(t, result, "components.NewB at src.go:13:6 requires *components.C")
+	assert.Contains(t, result, "components.NewC at src.go:15:6 requires *components.A")
+}
+
+func TestValidateIncludesCycles(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, cycleTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 2)
+	assert.IsType(t, &CycleError{}, errs[0])
+}
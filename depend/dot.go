@@ -0,0 +1,99 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bufio"
+	"fmt"
+	"go/types"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the graph of the Container to w in the Graphviz DOT
+// language. Each node for a function is labelled with the name of the function
+// qualified by the name of its package and each edge is labelled with the
+// components it carries from the provider to the requirer. Root nodes are
+// labelled with the names of their entry points and drawn as double circles.
+// Nodes for struct types and for values are labelled like those for functions
+// and drawn as rounded boxes and as ellipses. The node that stands in for the
+// missing providers, if any, is drawn as a dashed octagon.
+func (c *Container) WriteDOT(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "digraph dependencies {\n")
+	fmt.Fprintf(out, "\tnode [shape=box];\n")
+	nodes := c.graphNodes()
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case *rootNode:
+			fmt.Fprintf(out, "\tn%d [label=%s, shape=doublecircle];\n", node.ID(), strconv.Quote(node.entryPoint()))
+		case *missingNode:
+			fmt.Fprintf(out, "\tn%d [label=\"missing\", shape=octagon, style=dashed];\n", node.ID())
+		case *funcNode:
+			fmt.Fprintf(out, "\tn%d [label=%s];\n", node.ID(), strconv.Quote(qualifiedName(node.object())))
+		case *structNode:
+			fmt.Fprintf(out, "\tn%d [label=%s, style=rounded];\n", node.ID(), strconv.Quote(qualifiedName(node.object())))
+		case *valueNode:
+			fmt.Fprintf(out, "\tn%d [label=%s, shape=ellipse];\n", node.ID(), strconv.Quote(qualifiedName(node.object())))
+		default:
+			panic(fmt.Sprintf("WriteDOT: unknown kind of node %T", node))
+		}
+	}
+	for _, edge := range c.graphEdges(nodes) {
+		fmt.Fprintf(out, "\tn%d -> n%d [label=%s];\n", edge.from.ID(), edge.to.ID(), strconv.Quote(typeList(edge.types, "\n")))
+	}
+	fmt.Fprintf(out, "}\n")
+
+	return out.Flush()
+}
+
+// A graphEdge is an edge of the Container together with the components that
+// it carries.
+type graphEdge struct {
+	from, to commonNode
+	types    []types.Type
+}
+
+// graphNodes returns the nodes of the Container that are exported as part of
+// its graph, in the order of their IDs. The missingNode is only included if
+// some component is missing.
+func (c *Container) graphNodes() []commonNode {
+	c.ensureMissingNode()
+
+	var nodes []commonNode
+	for _, node := range c.nodes {
+		if node == c.missingNode && len(node.provides()) == 0 {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// graphEdges returns the edges between nodes, ordered by the IDs of their
+// providers and then of their requirers.
+func (c *Container) graphEdges(nodes []commonNode) []graphEdge {
+	var edges []graphEdge
+	for _, from := range nodes {
+		for _, to := range nodes {
+			if typs := c.edgeTypes(from, to); len(typs) > 0 {
+				edges = append(edges, graphEdge{from: from, to: to, types: typs})
+			}
+		}
+	}
+	return edges
+}
+
+// typeList returns the names of typs, qualified by the names of their
+// packages and separated by sep.
+func typeList(typs []types.Type, sep string) string {
+	names := make([]string, 0, len(typs))
+	for _, typ := range typs {
+		names = append(names, ComponentString(typ, packageNameQualifier))
+	}
+	return strings.Join(names, sep)
+}
@@ -0,0 +1,64 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDOTWritesLabelledNodesAndEdges(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteDOT(&out)
+
+	require.NoError(t, err)
+	assert.Equal(t, `digraph dependencies {
+	node [shape=box];
+	n0 [label="components.NewConfig"];
+	n1 [label="components.NewServer"];
+	n2 [label="Root", shape=doublecircle];
+	n3 [label="components.NewStore"];
+	n0 -> n1 [label="components.Config"];
+	n0 -> n3 [label="components.Config"];
+	n1 -> n2 [label="*components.Server"];
+	n3 -> n1 [label="*components.Store"];
+}
+`, out.String())
+}
+
+func TestWriteDOTWritesMissingNode(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, validateTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteDOT(&out)
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `[label="missing", shape=octagon, style=dashed];`)
+	assert.Contains(t, out.String(), `[label="components.Config\n*components.Logger"];`)
+}
+
+func TestWriteDOTWritesStructAndValueNodes(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	sut := &Container{}
+	require.NoError(t, sut.AddStruct(lookupNamed(pkg, "Server")))
+	require.NoError(t, sut.AddConst(pkg.Scope().Lookup("DefaultPort").(*types.Const)))
+
+	var out bytes.Buffer
+	err := sut.WriteDOT(&out)
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `n0 [label="components.Server", style=rounded];`)
+	assert.Contains(t, out.String(), `n2 [label="components.DefaultPort", shape=ellipse];`)
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"go/token"
 	"go/types"
+	"strings"
 )
 
 var (
@@ -22,8 +23,45 @@ var (
 	ErrRootAlreadySet = errors.New("root already set for container")
 
 	// ErrAmbiguousRootDetected is the error used to indicate that an attempt
-	// to auto-detect the root has found more than one root candidate.
+	// to auto-detect the root has found more than one root candidate. An
+	// AmbiguousRootError matches it with errors.Is.
 	ErrAmbiguousRootDetected = errors.New("root auto-detection found ambiguous root candidates")
+
+	// ErrInvalidRootName is the error used to indicate an attempt to add a
+	// named root whose name is not a valid Go identifier or is
+	// DefaultRootName.
+	ErrInvalidRootName = errors.New("root name is not an identifier")
+
+	// ErrDuplicateFuncName is the error used to indicate that two of the
+	// builder functions of a Container would have the same name.
+	ErrDuplicateFuncName = errors.New("builder function name used more than once")
+
+	// ErrNotInContainer is the error used to indicate that a declaration
+	// has not been added to a Container for an operation that requires it.
+	ErrNotInContainer = errors.New("declaration not added to container")
+
+	// ErrInvalidBinding is the error used to indicate an attempt to bind
+	// a type that is not an interface or to bind an interface to a type
+	// that does not implement it.
+	ErrInvalidBinding = errors.New("binding type does not implement interface")
+
+	// ErrAlreadyBound is the error used to indicate an attempt to bind an
+	// interface that is already bound to a different type.
+	ErrAlreadyBound = errors.New("interface already bound for container")
+
+	// ErrInvalidQualifier is the error used to indicate an attempt to
+	// qualify a component with a name that is not a valid Go identifier.
+	ErrInvalidQualifier = errors.New("qualifier is not an identifier")
+
+	// ErrUnknownParam is the error used to indicate an attempt to qualify
+	// the component required by a parameter or struct field that a
+	// declaration does not have.
+	ErrUnknownParam = errors.New("declaration has no such parameter or field")
+
+	// ErrParamObject is the error used to indicate an attempt to qualify the
+	// component required by a parameter object, which requires the
+	// components of its fields instead.
+	ErrParamObject = errors.New("parameter is a parameter object")
 )
 
 // An Error represents an error with an associated position in an
@@ -39,43 +77,380 @@ type Error interface {
 	ErrorWithPosition(fileSet *token.FileSet) string
 }
 
-// InvalidFuncError records an error with an attempt to add an invalid types.Func
-// to a Container. InvalidFuncError implements Error.
-type InvalidFuncError struct {
+// InvalidDeclError records an error with an attempt to add an invalid
+// declaration to a Container, such as a struct type that cannot be injected or
+// a variable or constant that cannot be provided. InvalidDeclError implements
+// Error.
+type InvalidDeclError struct {
 	pos      token.Pos
-	funcName string
+	kind     string
+	declName string
 	reason   string
 }
 
-func (ife *InvalidFuncError) Error() string {
+func (ide *InvalidDeclError) Error() string {
 	var buffer bytes.Buffer
-	buffer.WriteString("Invalid func for Container (")
-	buffer.WriteString(ife.funcName)
+	buffer.WriteString("Invalid ")
+	buffer.WriteString(ide.kind)
+	buffer.WriteString(" for Container (")
+	buffer.WriteString(ide.declName)
 	buffer.WriteString("): ")
-	buffer.WriteString(ife.reason)
+	buffer.WriteString(ide.reason)
 	return buffer.String()
 }
 
-func (ife *InvalidFuncError) Pos() token.Pos {
-	return ife.pos
+func (ide *InvalidDeclError) Pos() token.Pos {
+	return ide.pos
 }
 
-func (ife *InvalidFuncError) ErrorWithPosition(fileSet *token.FileSet) string {
+func (ide *InvalidDeclError) ErrorWithPosition(fileSet *token.FileSet) string {
 	var buffer bytes.Buffer
-	buffer.WriteString(fileSet.Position(ife.pos).String())
-	buffer.WriteString(": Invalid func for Container (")
-	buffer.WriteString(ife.funcName)
-	buffer.WriteString("): ")
-	buffer.WriteString(ife.reason)
+	buffer.WriteString(fileSet.Position(ide.pos).String())
+	buffer.WriteString(": ")
+	buffer.WriteString(ide.Error())
 	return buffer.String()
 }
 
-func newInvalidFuncError(function *types.Func, reason string) *InvalidFuncError {
-	return &InvalidFuncError{
-		pos:      function.Pos(),
-		funcName: function.Name(),
+// newInvalidDeclError returns an InvalidDeclError for decl whose kind is
+// "func", "struct", "var" or "const" according to the kind of decl.
+func newInvalidDeclError(decl types.Object, reason string) *InvalidDeclError {
+	kind := "declaration"
+	switch decl.(type) {
+	case *types.Func:
+		kind = "func"
+	case *types.TypeName:
+		kind = "struct"
+	case *types.Var:
+		kind = "var"
+	case *types.Const:
+		kind = "const"
+	}
+
+	return &InvalidDeclError{
+		pos:      decl.Pos(),
+		kind:     kind,
+		declName: decl.Name(),
 		reason:   reason,
 	}
 }
 
+var _ Error = &InvalidDeclError{}
+
+// InvalidFuncError records an error with an attempt to add an invalid types.Func
+// to a Container. InvalidFuncError implements Error.
+type InvalidFuncError struct {
+	InvalidDeclError
+}
+
+func newInvalidFuncError(function *types.Func, reason string) *InvalidFuncError {
+	return &InvalidFuncError{*newInvalidDeclError(function, reason)}
+}
+
 var _ Error = &InvalidFuncError{}
+
+// MissingDependencyError records a component that is required by some node in
+// a Container but is not provided by any node. MissingDependencyError
+// implements Error.
+type MissingDependencyError struct {
+	// Type is the component that is not provided.
+	Type types.Type
+
+	// RequiredBy are the declarations that require Type. It does not
+	// include the root.
+	RequiredBy []types.Object
+
+	// RequiredByRoot is whether Type is the root type of the Container.
+	RequiredByRoot bool
+
+	// Path is the chain of declarations through which the root requires
+	// Type, starting with the provider of the root and ending with a
+	// declaration that requires Type. It is empty if the root does not
+	// require Type or requires it directly.
+	Path []types.Object
+
+	// EntryPoints are the names of the roots from which the declarations
+	// that require Type can be reached, with DefaultRootName for the root
+	// that is not named. It is empty if the Container has no named roots.
+	EntryPoints []string
+}
+
+func (mde *MissingDependencyError) Error() string {
+	var buffer bytes.Buffer
+	mde.writeSummary(&buffer)
+	if mde.RequiredByRoot {
+		buffer.WriteString(" (required by the root)")
+	}
+	if len(mde.RequiredBy) > 0 {
+		buffer.WriteString(" (required by ")
+		for i, obj := range mde.RequiredBy {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+			buffer.WriteString(qualifiedName(obj))
+		}
+		buffer.WriteString(")")
+	}
+	writeEntryPoints(&buffer, mde.EntryPoints)
+	return buffer.String()
+}
+
+// Pos returns the position of the first declaration that requires Type or, if
+// only the root requires Type, the position of the declaration of the named
+// type that Type refers to. It returns token.NoPos if there is no such
+// declaration, in which case ErrorWithPosition omits the position.
+func (mde *MissingDependencyError) Pos() token.Pos {
+	if len(mde.RequiredBy) > 0 {
+		return mde.RequiredBy[0].Pos()
+	}
+	if mde.RequiredByRoot {
+		typ, _ := Unqualified(mde.Type)
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		if named, ok := typ.(*types.Named); ok {
+			return named.Obj().Pos()
+		}
+	}
+	return token.NoPos
+}
+
+func (mde *MissingDependencyError) ErrorWithPosition(fileSet *token.FileSet) string {
+	var buffer bytes.Buffer
+	if pos := mde.Pos(); pos.IsValid() {
+		buffer.WriteString(fileSet.Position(pos).String())
+		buffer.WriteString(": ")
+	}
+	mde.writeSummary(&buffer)
+	if mde.RequiredByRoot {
+		buffer.WriteString("\n\trequired by the root")
+	}
+	for _, obj := range mde.RequiredBy {
+		buffer.WriteString("\n\trequired by ")
+		buffer.WriteString(qualifiedName(obj))
+		buffer.WriteString(" at ")
+		buffer.WriteString(fileSet.Position(obj.Pos()).String())
+	}
+	if len(mde.Path) > 0 {
+		buffer.WriteString("\n\tpath from root: ")
+		writePath(&buffer, mde.Path)
+	}
+	writeEntryPointsWithPosition(&buffer, mde.EntryPoints)
+	return buffer.String()
+}
+
+func (mde *MissingDependencyError) writeSummary(buffer *bytes.Buffer) {
+	buffer.WriteString("Missing dependency: no provider for ")
+	buffer.WriteString(ComponentString(mde.Type, packageNameQualifier))
+}
+
+var _ Error = &MissingDependencyError{}
+
+// CycleError records a set of declarations whose requirements form a cycle.
+// CycleError implements Error.
+type CycleError struct {
+	// Cycle are the declarations in the cycle. Each declaration requires
+	// a component provided by the next declaration and the last declaration
+	// requires a component provided by the first.
+	Cycle []types.Object
+
+	// Types are the components passed along the cycle. Cycle[i] requires
+	// Types[i] which the next declaration in Cycle provides.
+	Types []types.Type
+
+	// EntryPoints are the names of the roots from which the cycle can be
+	// reached, with DefaultRootName for the root that is not named. It is
+	// empty if the Container has no named roots.
+	EntryPoints []string
+}
+
+func (ce *CycleError) Error() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("Dependency cycle: ")
+	for i, obj := range ce.Cycle {
+		buffer.WriteString(qualifiedName(obj))
+		buffer.WriteString(" requires ")
+		buffer.WriteString(ComponentString(ce.Types[i], packageNameQualifier))
+		buffer.WriteString(" from ")
+	}
+	buffer.WriteString(qualifiedName(ce.Cycle[0]))
+	writeEntryPoints(&buffer, ce.EntryPoints)
+	return buffer.String()
+}
+
+func (ce *CycleError) Pos() token.Pos {
+	return ce.Cycle[0].Pos()
+}
+
+func (ce *CycleError) ErrorWithPosition(fileSet *token.FileSet) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fileSet.Position(ce.Pos()).String())
+	buffer.WriteString(": Dependency cycle:")
+	for i, obj := range ce.Cycle {
+		buffer.WriteString("\n\t")
+		buffer.WriteString(qualifiedName(obj))
+		buffer.WriteString(" at ")
+		buffer.WriteString(fileSet.Position(obj.Pos()).String())
+		buffer.WriteString(" requires ")
+		buffer.WriteString(ComponentString(ce.Types[i], packageNameQualifier))
+	}
+	writeEntryPointsWithPosition(&buffer, ce.EntryPoints)
+	return buffer.String()
+}
+
+var _ Error = &CycleError{}
+
+// AmbiguousProviderError records a component that some node in a Container
+// requires and that more than one node provides. AmbiguousProviderError
+// implements Error.
+type AmbiguousProviderError struct {
+	// Type is the component with more than one provider.
+	Type types.Type
+
+	// Providers are the declarations that provide Type.
+	Providers []types.Object
+
+	// RequiredBy are the declarations that require Type. It does not
+	// include the root.
+	RequiredBy []types.Object
+
+	// EntryPoints are the names of the roots from which the declarations
+	// that require Type can be reached, with DefaultRootName for the root
+	// that is not named. It is empty if the Container has no named roots.
+	EntryPoints []string
+}
+
+func (ape *AmbiguousProviderError) Error() string {
+	var buffer bytes.Buffer
+	ape.writeSummary(&buffer)
+	buffer.WriteString(": ")
+	for i, obj := range ape.Providers {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		buffer.WriteString(qualifiedName(obj))
+	}
+	writeEntryPoints(&buffer, ape.EntryPoints)
+	return buffer.String()
+}
+
+func (ape *AmbiguousProviderError) Pos() token.Pos {
+	return ape.Providers[0].Pos()
+}
+
+func (ape *AmbiguousProviderError) ErrorWithPosition(fileSet *token.FileSet) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fileSet.Position(ape.Pos()).String())
+	buffer.WriteString(": ")
+	ape.writeSummary(&buffer)
+	for _, obj := range ape.Providers {
+		buffer.WriteString("\n\tprovided by ")
+		buffer.WriteString(qualifiedName(obj))
+		buffer.WriteString(" at ")
+		buffer.WriteString(fileSet.Position(obj.Pos()).String())
+	}
+	for _, obj := range ape.RequiredBy {
+		buffer.WriteString("\n\trequired by ")
+		buffer.WriteString(qualifiedName(obj))
+		buffer.WriteString(" at ")
+		buffer.WriteString(fileSet.Position(obj.Pos()).String())
+	}
+	writeEntryPointsWithPosition(&buffer, ape.EntryPoints)
+	return buffer.String()
+}
+
+func (ape *AmbiguousProviderError) writeSummary(buffer *bytes.Buffer) {
+	buffer.WriteString("Ambiguous providers for ")
+	buffer.WriteString(ComponentString(ape.Type, packageNameQualifier))
+}
+
+var _ Error = &AmbiguousProviderError{}
+
+// AmbiguousRootError records more than one root type auto-detected in a
+// Container whose root has not been set explicitly. AmbiguousRootError
+// implements Error.
+type AmbiguousRootError struct {
+	// Types are the candidate root types.
+	Types []types.Type
+
+	// Constructors are the declarations that provide the candidate root
+	// types. Constructors[i] provides Types[i].
+	Constructors []types.Object
+}
+
+func (are *AmbiguousRootError) Error() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("Ambiguous root: ")
+	for i, typ := range are.Types {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		buffer.WriteString(types.TypeString(typ, packageNameQualifier))
+		buffer.WriteString(" (from ")
+		buffer.WriteString(qualifiedName(are.Constructors[i]))
+		buffer.WriteString(")")
+	}
+	return buffer.String()
+}
+
+func (are *AmbiguousRootError) Pos() token.Pos {
+	return are.Constructors[0].Pos()
+}
+
+func (are *AmbiguousRootError) ErrorWithPosition(fileSet *token.FileSet) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fileSet.Position(are.Pos()).String())
+	buffer.WriteString(": Ambiguous root: select one of the candidate root types")
+	for i, typ := range are.Types {
+		buffer.WriteString("\n\t")
+		buffer.WriteString(types.TypeString(typ, packageNameQualifier))
+		buffer.WriteString(" from ")
+		buffer.WriteString(qualifiedName(are.Constructors[i]))
+		buffer.WriteString(" at ")
+		buffer.WriteString(fileSet.Position(are.Constructors[i].Pos()).String())
+	}
+	return buffer.String()
+}
+
+// Is reports whether target is ErrAmbiguousRootDetected.
+func (are *AmbiguousRootError) Is(target error) bool {
+	return target == ErrAmbiguousRootDetected
+}
+
+var _ Error = &AmbiguousRootError{}
+
+// qualifiedName returns the name of obj qualified by the name of its package, or
+// just the name of obj if it does not belong to a package.
+func qualifiedName(obj types.Object) string {
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Name() + "." + obj.Name()
+}
+
+func writePath(buffer *bytes.Buffer, path []types.Object) {
+	for i, obj := range path {
+		if i > 0 {
+			buffer.WriteString(" -> ")
+		}
+		buffer.WriteString(qualifiedName(obj))
+	}
+}
+
+func writeEntryPoints(buffer *bytes.Buffer, entryPoints []string) {
+	if len(entryPoints) > 0 {
+		buffer.WriteString(" (entry points: ")
+		buffer.WriteString(strings.Join(entryPoints, ", "))
+		buffer.WriteString(")")
+	}
+}
+
+func writeEntryPointsWithPosition(buffer *bytes.Buffer, entryPoints []string) {
+	if len(entryPoints) > 0 {
+		buffer.WriteString("\n\tentry points: ")
+		buffer.WriteString(strings.Join(entryPoints, ", "))
+	}
+}
+
+func packageNameQualifier(pkg *types.Package) string {
+	return pkg.Name()
+}
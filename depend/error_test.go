@@ -49,3 +49,109 @@ func TestInvaidFuncErrorIncludeFilenameInErrorWithPositon(t *testing.T) {
 
 	assert.Contains(t, result, filename, "Error string did not include the expected filename")
 }
+
+func TestMissingDependencyErrorIncludesTypeAndRequirers(t *testing.T) {
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	typ := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Config", nil), types.Typ[types.Int], nil)
+	sig := types.NewSignature(nil, types.NewTuple(), types.NewTuple(), false)
+	function := types.NewFunc(token.NoPos, pkg, "NewServer", sig)
+
+	sut := &MissingDependencyError{Type: typ, RequiredBy: []types.Object{function}}
+	result := sut.Error()
+
+	assert.Contains(t, result, "mypkg.Config", "Error string did not include the missing type")
+	assert.Contains(t, result, "mypkg.NewServer", "Error string did not include the requirer")
+}
+
+func TestMissingDependencyErrorWithPositionIncludesRequirersAndPath(t *testing.T) {
+	fileset := token.NewFileSet()
+	file := fileset.AddFile("myfile.go", -1, 100)
+	file.SetLines([]int{0, 20, 40})
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	typ := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Config", nil), types.Typ[types.Int], nil)
+	sig := types.NewSignature(nil, types.NewTuple(), types.NewTuple(), false)
+	server := types.NewFunc(file.Pos(25), pkg, "NewServer", sig)
+	app := types.NewFunc(file.Pos(45), pkg, "NewApp", sig)
+
+	sut := &MissingDependencyError{
+		Type:       typ,
+		RequiredBy: []types.Object{server},
+		Path:       []types.Object{app, server},
+	}
+	result := sut.ErrorWithPosition(fileset)
+
+	assert.Equal(t, `myfile.go:2:6: Missing dependency: no provider for mypkg.Config
+	required by mypkg.NewServer at myfile.go:2:6
+	path from root: mypkg.NewApp -> mypkg.NewServer`, result)
+}
+
+func TestMissingDependencyErrorOfRootUsesPositionOfRootType(t *testing.T) {
+	fileset := token.NewFileSet()
+	file := fileset.AddFile("myfile.go", -1, 100)
+	file.SetLines([]int{0, 20, 40})
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	typ := types.NewNamed(types.NewTypeName(file.Pos(25), pkg, "Server", nil), types.Typ[types.Int], nil)
+
+	sut := &MissingDependencyError{Type: types.NewPointer(typ), RequiredByRoot: true}
+	result := sut.ErrorWithPosition(fileset)
+
+	assert.Equal(t, `myfile.go:2:6: Missing dependency: no provider for *mypkg.Server
+	required by the root`, result)
+}
+
+func TestMissingDependencyErrorWithoutPositionOmitsIt(t *testing.T) {
+	fileset := token.NewFileSet()
+
+	sut := &MissingDependencyError{Type: types.Typ[types.Int], RequiredByRoot: true}
+	result := sut.ErrorWithPosition(fileset)
+
+	assert.Equal(t, `Missing dependency: no provider for int
+	required by the root`, result)
+}
+
+func TestAmbiguousProviderErrorIncludesTypeAndProviders(t *testing.T) {
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	other := types.NewPackage("github.com/sbosnick/otherpkg", "otherpkg")
+	typ := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Logger", nil), types.Typ[types.Int], nil)
+	sig := types.NewSignature(nil, types.NewTuple(), types.NewTuple(), false)
+	first := types.NewFunc(token.NoPos, pkg, "NewLogger", sig)
+	second := types.NewFunc(token.NoPos, other, "NewLogger", sig)
+
+	sut := &AmbiguousProviderError{Type: typ, Providers: []types.Object{first, second}}
+	result := sut.Error()
+
+	assert.Equal(t, "Ambiguous providers for mypkg.Logger: mypkg.NewLogger, otherpkg.NewLogger", result)
+}
+
+func TestAmbiguousRootErrorIncludesCandidatesAndConstructors(t *testing.T) {
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	server := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Server", nil), types.Typ[types.Int], nil)
+	worker := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Worker", nil), types.Typ[types.Int], nil)
+	sig := types.NewSignature(nil, types.NewTuple(), types.NewTuple(), false)
+	newServer := types.NewFunc(token.NoPos, pkg, "NewServer", sig)
+	newWorker := types.NewFunc(token.NoPos, pkg, "NewWorker", sig)
+
+	sut := &AmbiguousRootError{
+		Types:        []types.Type{server, worker},
+		Constructors: []types.Object{newServer, newWorker},
+	}
+	result := sut.Error()
+
+	assert.Equal(t, "Ambiguous root: mypkg.Server (from mypkg.NewServer), mypkg.Worker (from mypkg.NewWorker)", result)
+}
+
+func TestQualifiedNameQualifiesByPackageName(t *testing.T) {
+	pkg := types.NewPackage("github.com/sbosnick/myproject/components", "components")
+
+	assert.Equal(t, "components.Config", qualifiedName(types.NewTypeName(token.NoPos, pkg, "Config", nil)))
+	assert.Equal(t, "error", qualifiedName(types.Universe.Lookup("error")))
+}
+
+func TestInvalidDeclErrorIncludesKindOfDecl(t *testing.T) {
+	pkg := types.NewPackage("github.com/sbosnick/myproject/components", "components")
+	value := types.NewVar(token.NoPos, pkg, "defaultClock", types.Typ[types.Int])
+
+	sut := newInvalidDeclError(value, "No good reason.")
+
+	assert.Equal(t, "Invalid var for Container (defaultClock): No good reason.", sut.Error())
+}
@@ -4,16 +4,32 @@
 
 package depend
 
-import "go/types"
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+)
 
 // A funcNode generates a code fragment to produce instances of the provided
 // types by calling a function (a constructor or other static factory). Its
 // required types are the parameters to the function and its provided types
-// are the (non-error) results of the function.
+// are the (non-error) results of the function. A func() result that follows
+// the provided results is a cleanup function for those results rather than a
+// provided type. A parameter whose type is a parameter object (see
+// paramObject) requires the components of the fields of the object (see
+// objectKeys) instead of its own type, and a result whose type is a result
+// object (see resultObject) provides the types of its fields instead of its
+// own type. A funcNode for a named function provides components qualified by
+// its name and requires the components of the parameters in paramNames
+// qualified by their names (see Qualified).
 type funcNode struct {
-	container *Container
-	id        int
-	function  *types.Func
+	container  *Container
+	id         int
+	function   *types.Func
+	name       string
+	paramNames map[int]string
 }
 
 func newFuncNode(container *Container, id int, function *types.Func) (*funcNode, error) {
@@ -24,16 +40,44 @@ func newFuncNode(container *Container, id int, function *types.Func) (*funcNode,
 		return nil, newInvalidFuncError(function, "cannot add methods to a Container")
 	}
 
+	// Check for a generic function.
+	if sig.TypeParams().Len() > 0 {
+		return nil, newInvalidFuncError(function, "cannot add generic functions to a Container")
+	}
+
 	// Check for an error return type that is not the last return type
 	if tupleHasEarlyError(sig.Results()) {
 		return nil, newInvalidFuncError(function, "error return type must be last return type")
 	}
 
+	// Check for a parameter object field with an invalid qualifier.
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		st := paramObject(params.At(i).Type())
+		if st == nil {
+			continue
+		}
+		for j := 0; j < st.NumFields(); j++ {
+			if name := fieldName(st, j); name != "" && !token.IsIdentifier(name) {
+				return nil, newInvalidFuncError(function, "field "+st.Field(j).Name()+" of parameter "+params.At(i).Name()+" has a qualifier that is not an identifier")
+			}
+		}
+	}
+
 	node := &funcNode{
 		container: container,
 		id:        id,
 		function:  function,
 	}
+
+	// Check for a component provided by more than one result.
+	provided := node.provides()
+	for i, typ := range provided {
+		if _, found := findType(provided[:i], typ); found {
+			return nil, newInvalidFuncError(function, "provides "+types.TypeString(typ, packageNameQualifier)+" more than once")
+		}
+	}
+
 	return node, nil
 }
 
@@ -44,33 +88,307 @@ func (f funcNode) ID() int {
 	return f.id
 }
 
-func (f funcNode) Generate() {
-	panic("not implemented")
+// Generate writes a call to the function that assigns each of its results to
+// the variable named for the result's type. The argument for a parameter
+// object is a composite literal that sets each of its fields and each field of
+// a result object that is required by another node is assigned to the
+// variable named for the field's type, by way of a variable for the object
+// that no other result object shares. Results that are not required by any
+// other nodes are discarded. A non-nil error result is returned from the
+// builder function, wrapped with the name of the function, after calling the
+// cleanup functions of the functions called before this one.
+func (f funcNode) Generate(gen *genContext) {
+	sig := f.function.Type().(*types.Signature)
+
+	var args []string
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		var arg string
+		if st := paramObject(params.At(i).Type()); st != nil {
+			arg = gen.compositeLiteral(params.At(i).Type(), objectFields(st), objectKeys(st))
+		} else {
+			arg = gen.argName(f.paramKey(i))
+		}
+		if sig.Variadic() && i == params.Len()-1 {
+			arg += "..."
+		}
+		args = append(args, arg)
+	}
+	call := gen.objectString(f.function) + "(" + strings.Join(args, ", ") + ")"
+
+	var lhs []string
+	var cleanup string
+	var fields []string
+	declares := false
+	returnsErr := false
+	results := sig.Results()
+	for i := 0; i < results.Len(); i++ {
+		typ := results.At(i).Type()
+		switch {
+		case i == f.cleanupIndex():
+			cleanup = gen.nextCleanupName()
+			lhs = append(lhs, cleanup)
+			declares = true
+		case isErrorType(typ):
+			lhs = append(lhs, "err")
+			returnsErr = true
+		case resultObject(typ) != nil:
+			var required []*types.Var
+			for _, field := range objectFields(resultObject(typ)) {
+				if gen.isRequired(Qualified(field.Type(), f.name)) {
+					required = append(required, field)
+				}
+			}
+			if len(required) == 0 {
+				lhs = append(lhs, "_")
+				continue
+			}
+			object := gen.objectName(Qualified(typ, f.name))
+			for _, field := range required {
+				key := Qualified(field.Type(), f.name)
+				fields = append(fields, fmt.Sprintf("%s := %s.%s\n", gen.varName(key), object, field.Name()))
+			}
+			lhs = append(lhs, object)
+			declares = true
+		case gen.isRequired(Qualified(typ, f.name)):
+			lhs = append(lhs, gen.varName(Qualified(typ, f.name)))
+			declares = true
+		default:
+			lhs = append(lhs, "_")
+		}
+	}
+
+	switch {
+	case !declares && !returnsErr:
+		gen.printf("%s\n", call)
+	case !declares:
+		// Use a scoped err so that the statement declares a new variable.
+		gen.printf("if %s := %s; err != nil {\n", strings.Join(lhs, ", "), call)
+		gen.printFailure(f.function)
+		gen.printf("}\n")
+	default:
+		gen.printf("%s := %s\n", strings.Join(lhs, ", "), call)
+		if returnsErr {
+			gen.printf("if err != nil {\n")
+			gen.printFailure(f.function)
+			gen.printf("}\n")
+		}
+	}
+
+	for _, field := range fields {
+		gen.printf("%s", field)
+	}
+
+	// the cleanup is only needed once the function has succeeded
+	if cleanup != "" {
+		gen.cleanups = append(gen.cleanups, cleanup)
+	}
+}
+
+// returnsCleanup returns whether the function returns a cleanup function. A
+// cleanup function is a func() result that is the last non-error result and
+// that follows at least one other result.
+func (f funcNode) returnsCleanup() bool {
+	return f.cleanupIndex() >= 0
+}
+
+// cleanupIndex returns the index of the cleanup function in the results of the
+// function or -1 if the function does not return a cleanup function.
+func (f funcNode) cleanupIndex() int {
+	results := f.function.Type().(*types.Signature).Results()
+
+	last := results.Len() - 1
+	if f.returnsError() {
+		last--
+	}
+	if last < 1 || !isCleanupType(results.At(last).Type()) {
+		return -1
+	}
+
+	return last
+}
+
+// returnsError returns whether the last result of the function is an error.
+func (f funcNode) returnsError() bool {
+	results := f.function.Type().(*types.Signature).Results()
+	return results.Len() > 0 && isErrorType(results.At(results.Len()-1).Type())
 }
 
 func (f funcNode) requires() []types.Type {
-	sig := f.function.Type().(*types.Signature)
+	var result []types.Type
+
+	params := f.function.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len(); i++ {
+		if st := paramObject(params.At(i).Type()); st != nil {
+			result = append(result, objectKeys(st)...)
+			continue
+		}
+		result = append(result, f.paramKey(i))
+	}
+
+	return result
+}
+
+// paramKey returns the component required by the i'th parameter of the
+// function.
+func (f funcNode) paramKey(i int) types.Type {
+	params := f.function.Type().(*types.Signature).Params()
+	return Qualified(params.At(i).Type(), f.paramNames[i])
+}
 
-	return extractTypesForTuple(sig.Params(), false)
+// setName qualifies the components provided by the function by name.
+func (f *funcNode) setName(name string) {
+	f.name = name
+}
+
+// setParamName qualifies the component required by the parameter param of the
+// function by name. It returns ErrUnknownParam if there is no such parameter
+// and ErrParamObject if the parameter is a parameter object.
+func (f *funcNode) setParamName(param string, name string) error {
+	params := f.function.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len(); i++ {
+		if params.At(i).Name() == param {
+			if paramObject(params.At(i).Type()) != nil {
+				return ErrParamObject
+			}
+			if f.paramNames == nil {
+				f.paramNames = make(map[int]string)
+			}
+			f.paramNames[i] = name
+			return nil
+		}
+	}
+
+	return ErrUnknownParam
 }
 
 func (f funcNode) provides() []types.Type {
 	sig := f.function.Type().(*types.Signature)
 
-	return extractTypesForTuple(sig.Results(), true)
+	result := extractTypesForTuple(sig.Results(), true)
+	if f.returnsCleanup() {
+		// the cleanup is the last of the non-error results
+		result = result[:len(result)-1]
+	}
+
+	var provided []types.Type
+	for _, typ := range result {
+		if st := resultObject(typ); st != nil {
+			for _, field := range objectFields(st) {
+				provided = append(provided, Qualified(field.Type(), f.name))
+			}
+			continue
+		}
+		provided = append(provided, Qualified(typ, f.name))
+	}
+
+	return provided
 }
 
 func (f funcNode) getContainer() *Container {
 	return f.container
 }
 
+func (f funcNode) object() types.Object {
+	return f.function
+}
+
+// markerPath is the import path of the package that holds the marker types
+// In and Out.
+const markerPath = "github.com/sbosnick/dibuilder"
+
+// paramObject returns the struct type of typ if typ is a parameter object, a
+// struct type that embeds dibuilder.In, and nil otherwise.
+func paramObject(typ types.Type) *types.Struct {
+	return markedStruct(typ, "In")
+}
+
+// markedStruct returns the struct type of typ if typ is a struct type that
+// embeds the marker type named marker, and nil otherwise.
+func markedStruct(typ types.Type, marker string) *types.Struct {
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		if isMarker(st.Field(i), marker) {
+			return st
+		}
+	}
+
+	return nil
+}
+
+// isMarker returns whether field is the embedded marker type named marker.
+func isMarker(field *types.Var, marker string) bool {
+	if !field.Embedded() {
+		return false
+	}
+
+	named, ok := field.Type().(*types.Named)
+	return ok && named.Obj().Name() == marker &&
+		named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == markerPath
+}
+
+// resultObject returns the struct type of typ if typ is a result object, a
+// struct type that embeds dibuilder.Out, and nil otherwise.
+func resultObject(typ types.Type) *types.Struct {
+	return markedStruct(typ, "Out")
+}
+
+// objectFields returns the fields of the parameter or result object st that
+// hold components. These are the exported fields other than the markers.
+func objectFields(st *types.Struct) []*types.Var {
+	var result []*types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		if isObjectField(st.Field(i)) {
+			result = append(result, st.Field(i))
+		}
+	}
+	return result
+}
+
+// isObjectField returns whether field is a field of a parameter or result
+// object that holds a component.
+func isObjectField(field *types.Var) bool {
+	return field.Exported() && !isMarker(field, "In") && !isMarker(field, "Out")
+}
+
+// objectKeys returns the components required by the fields of the parameter
+// object st in the order of objectFields. The component of a field is its
+// type qualified by the name in its tag, if any (see fieldName).
+func objectKeys(st *types.Struct) []types.Type {
+	var result []types.Type
+	for i := 0; i < st.NumFields(); i++ {
+		if isObjectField(st.Field(i)) {
+			result = append(result, Qualified(st.Field(i).Type(), fieldName(st, i)))
+		}
+	}
+	return result
+}
+
+// nameTagPrefix starts the value of the "dibuilder" key in the tag of a field
+// of a parameter object that qualifies the component the field requires.
+const nameTagPrefix = "name="
+
+// fieldName returns the qualifier of the component required by the i'th field
+// of the parameter object st. This is replica for a field tagged with
+// `dibuilder:"name=replica"` and empty for any other field.
+func fieldName(st *types.Struct, i int) string {
+	tag := reflect.StructTag(st.Tag(i)).Get("dibuilder")
+	if !strings.HasPrefix(tag, nameTagPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(tag, nameTagPrefix)
+}
+
 func extractTypesForTuple(tuple *types.Tuple, excludeError bool) []types.Type {
 	var result []types.Type
-	errType := types.Universe.Lookup("error").Type()
 
 	for i := 0; i < tuple.Len(); i++ {
 		typ := tuple.At(i).Type()
-		if !excludeError || !types.Identical(typ, errType) {
+		if !excludeError || !isErrorType(typ) {
 			result = append(result, tuple.At(i).Type())
 		}
 	}
@@ -79,12 +397,10 @@ func extractTypesForTuple(tuple *types.Tuple, excludeError bool) []types.Type {
 }
 
 func tupleHasEarlyError(tuple *types.Tuple) bool {
-	errType := types.Universe.Lookup("error").Type()
-
 	// Note: "tuple.Len() - 1" is correct because an error
 	// as the last return type should return false
 	for i := 0; i < tuple.Len()-1; i++ {
-		if types.Identical(tuple.At(i).Type(), errType) {
+		if isErrorType(tuple.At(i).Type()) {
 			return true
 		}
 	}
@@ -92,4 +408,14 @@ func tupleHasEarlyError(tuple *types.Tuple) bool {
 	return false
 }
 
+func isCleanupType(typ types.Type) bool {
+	sig, ok := typ.Underlying().(*types.Signature)
+	return ok && sig.Params().Len() == 0 && sig.Results().Len() == 0 && !sig.Variadic()
+}
+
+func isErrorType(typ types.Type) bool {
+	return types.Identical(typ, types.Universe.Lookup("error").Type())
+}
+
 var _ commonNode = funcNode{}
+var _ qualifiedNode = &funcNode{}
@@ -5,8 +5,10 @@
 package depend
 
 import (
+	"bytes"
 	"go/token"
 	"go/types"
+	"strings"
 	"testing"
 
 	"github.com/cheekybits/is"
@@ -96,6 +98,21 @@ func TestNewFuncNodeWithEarlyErrorReturnIsError(t *testing.T) {
 	assert.IsType(t, &InvalidFuncError{}, err, "Error return not of expected type")
 }
 
+func TestNewFuncNodeWithDuplicateResultIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, `package components
+
+type A struct{}
+
+func NewPair() (*A, *A) { return nil, nil }
+`)
+
+	_, err := newFuncNode(nil, 0, pkg.Scope().Lookup("NewPair").(*types.Func))
+
+	require.Error(t, err, "Expected error was not returned")
+	assert.IsType(t, &InvalidFuncError{}, err, "Error return not of expected type")
+	assert.Contains(t, err.Error(), "provides *components.A more than once")
+}
+
 func TestNewFuncNodeWithNoErrorReturnGivesNode(t *testing.T) {
 	function := makeFunc(types.Typ[types.Int], types.Typ[types.Bool], false)
 
@@ -276,3 +293,441 @@ func TestContainerWithFuncProvidingRequiredFuncHasEdgeFromFuncToFunc(t *testing.
 
 	is.OK(u, v, result)
 }
+
+func TestFuncNodeGenerateAssignsRequiredResult(t *testing.T) {
+	is := is.New(t)
+	param := makeNamedType("MyParam", types.Typ[types.Int])
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+	gen := makeGenContext(param, ret)
+
+	sut := funcNode{function: makeFunc(param, ret, false)}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "myResult := myfunc(myParam)\n")
+}
+
+func TestFuncNodeGenerateDiscardsUnrequiredResult(t *testing.T) {
+	is := is.New(t)
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+	gen := makeGenContext()
+
+	sut := funcNode{function: makeFunc(nil, ret, false)}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "myfunc()\n")
+}
+
+func TestFuncNodeGeneratePanicsOnErrorResult(t *testing.T) {
+	is := is.New(t)
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+	gen := makeGenContext(ret)
+
+	sut := funcNode{function: makeFunc(nil, ret, true)}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "myResult, err := myfunc()\nif err != nil {\npanic(err)\n}\n")
+}
+
+func TestFuncNodeGenerateScopesErrorWithoutRequiredResult(t *testing.T) {
+	is := is.New(t)
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+	gen := makeGenContext()
+
+	sut := funcNode{function: makeFunc(nil, ret, true)}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "if _, err := myfunc(); err != nil {\npanic(err)\n}\n")
+}
+
+func TestFuncNodeGenerateQualifiesFunction(t *testing.T) {
+	is := is.New(t)
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+	function := types.NewFunc(token.NoPos, pkg, "NewMyResult", makeSignature(nil, ret, false))
+	gen := makeGenContext(ret)
+
+	sut := funcNode{function: function}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "myResult := mypkg.NewMyResult()\n")
+}
+
+func TestFuncNodeGenerateExpandsVariadicParameter(t *testing.T) {
+	is := is.New(t)
+	option := makeNamedType("Option", types.Typ[types.Int])
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+	sig := types.NewSignature(nil,
+		types.NewTuple(types.NewVar(token.NoPos, nil, "opts", types.NewSlice(option))),
+		types.NewTuple(types.NewVar(token.NoPos, nil, "", ret)),
+		true)
+	function := types.NewFunc(token.NoPos, nil, "myfunc", sig)
+	gen := makeGenContext(ret)
+
+	sut := funcNode{function: function}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "myResult := myfunc(option...)\n")
+}
+
+func TestNewFuncNodeWithGenericFunctionIsError(t *testing.T) {
+	typename := types.NewTypeName(token.NoPos, nil, "T", nil)
+	typeParam := types.NewTypeParam(typename, types.NewInterfaceType(nil, nil))
+	sig := types.NewSignatureType(nil, nil, []*types.TypeParam{typeParam},
+		types.NewTuple(), types.NewTuple(types.NewParam(token.NoPos, nil, "", typeParam)), false)
+	function := types.NewFunc(token.NoPos, nil, "MyFunc", sig)
+
+	_, err := newFuncNode(nil, 0, function)
+
+	require.Error(t, err, "Expected error was not returned")
+	assert.IsType(t, &InvalidFuncError{}, err, "Error return not of expected type")
+}
+
+func TestFuncNodeGenerateReturnsWrappedError(t *testing.T) {
+	is := is.New(t)
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+	function := types.NewFunc(token.NoPos, pkg, "NewMyResult", makeSignature(nil, ret, true))
+	gen := makeGenContext(ret)
+	gen.root = types.NewPointer(ret)
+	gen.returnsErr = true
+
+	sut := funcNode{function: function}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "myResult, err := mypkg.NewMyResult()\nif err != nil {\n"+
+		"return nil, fmt.Errorf(\"mypkg.NewMyResult: %w\", err)\n}\n")
+}
+
+func TestFuncNodeReturnsError(t *testing.T) {
+	is := is.New(t)
+
+	is.True(funcNode{function: makeFunc(nil, types.Typ[types.Int], true)}.returnsError())
+	is.False(funcNode{function: makeFunc(nil, types.Typ[types.Int], false)}.returnsError())
+	is.False(funcNode{function: makeFunc(nil, nil, false)}.returnsError())
+}
+
+func makeCleanupFunc(ret types.Type, returnsErr bool) *types.Func {
+	cleanup := types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(), false)
+	results := []*types.Var{
+		types.NewVar(token.NoPos, nil, "", ret),
+		types.NewVar(token.NoPos, nil, "", cleanup),
+	}
+	if returnsErr {
+		results = append(results, types.NewVar(token.NoPos, nil, "", types.Universe.Lookup("error").Type()))
+	}
+	sig := types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(results...), false)
+	return types.NewFunc(token.NoPos, nil, "myfunc", sig)
+}
+
+func TestFuncNodeDoesNotProvideCleanup(t *testing.T) {
+	is := is.New(t)
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+
+	for _, returnsErr := range []bool{false, true} {
+		sut := funcNode{function: makeCleanupFunc(ret, returnsErr)}
+
+		is.True(sut.returnsCleanup())
+		is.Equal(sut.provides(), []types.Type{ret})
+	}
+}
+
+func TestFuncNodeReturningOnlyFuncHasNoCleanup(t *testing.T) {
+	is := is.New(t)
+	cleanup := types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(), false)
+
+	sut := funcNode{function: makeFunc(nil, cleanup, true)}
+
+	is.False(sut.returnsCleanup())
+	is.Equal(len(sut.provides()), 1)
+}
+
+func TestFuncNodeGenerateRecordsCleanupAfterErrorCheck(t *testing.T) {
+	is := is.New(t)
+	ret := makeNamedType("MyResult", types.Typ[types.Int])
+	gen := makeGenContext(ret)
+	gen.root = types.NewPointer(ret)
+	gen.returnsErr = true
+	gen.returnsCleanup = true
+	gen.cleanups = []string{"cleanup"}
+	gen.cleanupCount = 1
+
+	sut := funcNode{function: makeCleanupFunc(ret, true)}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "myResult, cleanup2, err := myfunc()\nif err != nil {\ncleanup()\n"+
+		"return nil, nil, fmt.Errorf(\"myfunc: %w\", err)\n}\n")
+	is.Equal(gen.cleanups, []string{"cleanup", "cleanup2"})
+}
+
+const markerSrc = `package dibuilder
+
+type In struct{}
+
+type Out struct{}
+`
+
+const paramObjectTestSrc = `package components
+
+import "github.com/sbosnick/dibuilder"
+
+type Config struct{}
+
+type Logger struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+type ServerDeps struct {
+	dibuilder.In
+
+	Config *Config
+	Logger *Logger
+	port   int
+}
+
+func NewConfig() *Config { return nil }
+
+func NewLogger() *Logger { return nil }
+
+func NewServer(deps ServerDeps, logger *Logger) *Server { return nil }
+`
+
+func loadParamObjectTestPackage(t *testing.T) (*types.Package, *types.Package) {
+	marker, _ := loadTestPackage(t, markerPath, markerSrc)
+	pkg, _ := loadTestPackage(t, testComponentsPath, paramObjectTestSrc, marker)
+	return pkg, marker
+}
+
+func TestFuncNodeRequiresFieldsOfParamObject(t *testing.T) {
+	pkg, _ := loadParamObjectTestPackage(t)
+	function := pkg.Scope().Lookup("NewServer").(*types.Func)
+
+	sut := funcNode{function: function}
+	requires := sut.requires()
+
+	require.Len(t, requires, 3)
+	assert.Equal(t, "*github.com/sbosnick/myproject/components.Config", requires[0].String())
+	assert.Equal(t, "*github.com/sbosnick/myproject/components.Logger", requires[1].String())
+	assert.Equal(t, "*github.com/sbosnick/myproject/components.Logger", requires[2].String())
+}
+
+func TestWriteBuilderBuildsParamObjectInline(t *testing.T) {
+	pkg, marker := loadParamObjectTestPackage(t)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "server := components.NewServer(components.ServerDeps{\n\t\tConfig: config,\n\t\tLogger: logger,\n\t}, logger)")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg, marker))
+}
+
+func TestNameParamOfParamObjectIsError(t *testing.T) {
+	pkg, _ := loadParamObjectTestPackage(t)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	err := sut.NameParam(pkg.Scope().Lookup("NewServer"), "deps", "main")
+
+	assert.Equal(t, ErrParamObject, err)
+}
+
+const qualifiedParamObjectTestSrc = `package components
+
+import "github.com/sbosnick/dibuilder"
+
+type DB struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+type ServerDeps struct {
+	dibuilder.In
+
+	Primary *DB "dibuilder:\"name=primary\""
+	Replica *DB "dibuilder:\"name=replica\""
+}
+
+func NewPrimary() *DB { return nil }
+
+func NewReplica() *DB { return nil }
+
+func NewServer(deps ServerDeps) *Server { return nil }
+`
+
+func TestFuncNodeRequiresQualifiedFieldsOfParamObject(t *testing.T) {
+	marker, _ := loadTestPackage(t, markerPath, markerSrc)
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifiedParamObjectTestSrc, marker)
+	db := types.NewPointer(pkg.Scope().Lookup("DB").Type())
+
+	sut := funcNode{function: pkg.Scope().Lookup("NewServer").(*types.Func)}
+	requires := sut.requires()
+
+	require.Len(t, requires, 2)
+	assert.True(t, types.Identical(Qualified(db, "primary"), requires[0]))
+	assert.True(t, types.Identical(Qualified(db, "replica"), requires[1]))
+}
+
+func TestWriteBuilderSetsQualifiedFieldsOfParamObject(t *testing.T) {
+	marker, _ := loadTestPackage(t, markerPath, markerSrc)
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifiedParamObjectTestSrc, marker)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.Name(pkg.Scope().Lookup("NewPrimary"), "primary"))
+	require.NoError(t, sut.Name(pkg.Scope().Lookup("NewReplica"), "replica"))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "server := components.NewServer(components.ServerDeps{\n\t\tPrimary: primaryDB,\n\t\tReplica: replicaDB,\n\t})")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg, marker))
+}
+
+func TestNewFuncNodeWithInvalidFieldQualifierIsError(t *testing.T) {
+	src := strings.Replace(qualifiedParamObjectTestSrc, "name=replica", "name=read-only", 1)
+	marker, _ := loadTestPackage(t, markerPath, markerSrc)
+	pkg, _ := loadTestPackage(t, testComponentsPath, src, marker)
+
+	_, err := newFuncNode(nil, 0, pkg.Scope().Lookup("NewServer").(*types.Func))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field Replica of parameter deps has a qualifier that is not an identifier")
+}
+
+const resultObjectTestSrc = `package components
+
+import "github.com/sbosnick/dibuilder"
+
+type Handler struct{}
+
+type Registry struct{}
+
+type Unused struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+type Outputs struct {
+	dibuilder.Out
+
+	Handler *Handler
+	Metrics *Registry
+	Unused  *Unused
+}
+
+func NewOutputs() (Outputs, error) { return Outputs{}, nil }
+
+func NewServer(handler *Handler, metrics *Registry) *Server { return nil }
+`
+
+func TestFuncNodeProvidesFieldsOfResultObject(t *testing.T) {
+	marker, _ := loadTestPackage(t, markerPath, markerSrc)
+	pkg, _ := loadTestPackage(t, testComponentsPath, resultObjectTestSrc, marker)
+	function := pkg.Scope().Lookup("NewOutputs").(*types.Func)
+
+	sut := funcNode{function: function}
+	provides := sut.provides()
+
+	require.Len(t, provides, 3)
+	assert.Equal(t, "*github.com/sbosnick/myproject/components.Handler", provides[0].String())
+	assert.Equal(t, "*github.com/sbosnick/myproject/components.Registry", provides[1].String())
+	assert.Equal(t, "*github.com/sbosnick/myproject/components.Unused", provides[2].String())
+}
+
+func TestWriteBuilderDestructuresResultObject(t *testing.T) {
+	marker, _ := loadTestPackage(t, markerPath, markerSrc)
+	pkg, _ := loadTestPackage(t, testComponentsPath, resultObjectTestSrc, marker)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "outputs, err := components.NewOutputs()\n"+
+		"\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"components.NewOutputs: %w\", err)\n\t}\n"+
+		"\thandler := outputs.Handler\n\tregistry := outputs.Metrics\n")
+	assert.NotContains(t, out.String(), "outputs.Unused")
+	fmtPkg, _ := loadTestPackage(t, "fmt", fmtSrc)
+	assert.NoError(t, typecheckGenerated(out.String(), pkg, marker, fmtPkg))
+}
+
+const resultObjectsTestSrc = `package components
+
+import "github.com/sbosnick/dibuilder"
+
+type Handler struct{}
+
+type Registry struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+type Handlers struct {
+	dibuilder.Out
+
+	Handler *Handler
+}
+
+type Registries struct {
+	dibuilder.Out
+
+	Registry *Registry
+}
+
+func NewBoth() (Handlers, Registries) { return Handlers{}, Registries{} }
+
+func NewPrimary() Handlers { return Handlers{} }
+
+func NewReplica() Handlers { return Handlers{} }
+
+func NewServer(handler *Handler, primary *Handler, replica *Handler) *Server { return nil }
+`
+
+// addResultObjectConstructors adds the constructors in resultObjectsTestSrc
+// to container with NewPrimary and NewReplica providing the qualified
+// components primary and replica and with NewServer requiring them.
+func addResultObjectConstructors(t *testing.T, container *Container) *types.Package {
+	marker, _ := loadTestPackage(t, markerPath, markerSrc)
+	pkg, _ := loadTestPackage(t, testComponentsPath, resultObjectsTestSrc, marker)
+	addConstructors(t, container, pkg)
+	scope := pkg.Scope()
+	require.NoError(t, container.Name(scope.Lookup("NewPrimary"), "primary"))
+	require.NoError(t, container.Name(scope.Lookup("NewReplica"), "replica"))
+	require.NoError(t, container.NameParam(scope.Lookup("NewServer"), "primary", "primary"))
+	require.NoError(t, container.NameParam(scope.Lookup("NewServer"), "replica", "replica"))
+	return pkg
+}
+
+func TestWriteBuilderDiscardsUnrequiredResultObject(t *testing.T) {
+	sut := &Container{}
+	pkg := addResultObjectConstructors(t, sut)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "handlers, _ := components.NewBoth()\n\thandler := handlers.Handler\n")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg, pkg.Imports()[0]))
+}
+
+func TestWriteBuilderNamesNamedResultObjects(t *testing.T) {
+	sut := &Container{}
+	pkg := addResultObjectConstructors(t, sut)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "primaryHandlers := components.NewPrimary()\n\tprimaryHandler := primaryHandlers.Handler\n")
+	assert.Contains(t, out.String(), "replicaHandlers := components.NewReplica()\n\treplicaHandler := replicaHandlers.Handler\n")
+	assert.Contains(t, out.String(), "components.NewServer(handler, primaryHandler, replicaHandler)")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg, pkg.Imports()[0]))
+}
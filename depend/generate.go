@@ -0,0 +1,251 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// A genContext holds the state shared by the nodes of a Container while they
+// generate the code fragments that make up the body of a builder function.
+// The variable for a type is named consistently across all of the nodes so
+// that the variable assigned by a provider is the one used by its requirers.
+type genContext struct {
+	out       bytes.Buffer
+	namer     *varNamer
+	required  *typeSet
+	qualifier types.Qualifier
+	resolve   func(types.Type) types.Type
+
+	// root is the type returned by the builder function, returnsCleanup is
+	// whether the builder function also returns a cleanup function and
+	// returnsErr is whether the builder function also returns an error.
+	root           types.Type
+	returnsCleanup bool
+	returnsErr     bool
+
+	// cleanups are the variables that hold the cleanup functions returned
+	// so far, in the order they were returned.
+	cleanups     []string
+	cleanupCount int
+
+	// objects are the types of the result objects assigned so far.
+	objects []types.Type
+}
+
+// errorReturner is implemented by the nodes that can fail.
+type errorReturner interface {
+	returnsError() bool
+}
+
+// cleanupReturner is implemented by the nodes that can produce a cleanup
+// function for the instances of their provided types.
+type cleanupReturner interface {
+	returnsCleanup() bool
+}
+
+var fmtPackage = types.NewPackage("fmt", "fmt")
+
+func newGenContext(hasher typeutil.Hasher, qualifier types.Qualifier) *genContext {
+	return &genContext{
+		namer:     newVarNamer(hasher),
+		required:  newTypeSet(hasher),
+		qualifier: qualifier,
+		resolve:   func(typ types.Type) types.Type { return typ },
+	}
+}
+
+// printf appends formatted source code to the generated code fragment.
+func (g *genContext) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.out, format, args...)
+}
+
+// require records that some node whose code is being generated requires typ.
+func (g *genContext) require(typ types.Type) {
+	g.required.Add(g.resolve(typ))
+}
+
+// isRequired returns whether some node whose code is being generated requires typ.
+func (g *genContext) isRequired(typ types.Type) bool {
+	return g.required.Has(typ)
+}
+
+// varName returns the name of the variable that holds the instance of typ.
+func (g *genContext) varName(typ types.Type) string {
+	return g.namer.Name(typ, 0)
+}
+
+// objectName returns the name of a new variable for an instance of the
+// result object typ. Each call returns a distinct name, even for the same
+// type.
+func (g *genContext) objectName(typ types.Type) string {
+	instance := 0
+	for _, object := range g.objects {
+		if types.Identical(object, typ) {
+			instance++
+		}
+	}
+	g.objects = append(g.objects, typ)
+
+	return g.namer.Name(typ, instance)
+}
+
+// argName returns the name of the variable that satisfies a requirement for
+// typ. This is the variable for the type bound to typ, if any.
+func (g *genContext) argName(typ types.Type) string {
+	return g.varName(g.resolve(typ))
+}
+
+// compositeLiteral returns a composite literal of the struct type typ that
+// sets each of fields to the variable that satisfies a requirement for the
+// component at the same index in keys.
+func (g *genContext) compositeLiteral(typ types.Type, fields []*types.Var, keys []types.Type) string {
+	if len(fields) == 0 {
+		return g.typeString(typ) + "{}"
+	}
+
+	var elements []string
+	for i, field := range fields {
+		elements = append(elements, field.Name()+": "+g.argName(keys[i])+",\n")
+	}
+	return g.typeString(typ) + "{\n" + strings.Join(elements, "") + "}"
+}
+
+// typeString returns the source representation of typ. The source
+// representation of a qualified component is that of its type.
+func (g *genContext) typeString(typ types.Type) string {
+	typ, _ = Unqualified(typ)
+	return types.TypeString(typ, g.qualifier)
+}
+
+// componentString returns the representation of the component typ in a
+// comment of the generated code.
+func (g *genContext) componentString(typ types.Type) string {
+	return ComponentString(typ, g.qualifier)
+}
+
+// cleanupName returns the name of the variable for the i'th cleanup function.
+func cleanupName(i int) string {
+	if i == 0 {
+		return "cleanup"
+	}
+	return "cleanup" + strconv.Itoa(i+1)
+}
+
+// nextCleanupName returns the name of the variable for the next cleanup function.
+func (g *genContext) nextCleanupName() string {
+	name := cleanupName(g.cleanupCount)
+	g.cleanupCount++
+	return name
+}
+
+// printCleanups writes calls to the cleanup functions returned so far in the
+// reverse of the order they were returned.
+func (g *genContext) printCleanups() {
+	for i := len(g.cleanups) - 1; i >= 0; i-- {
+		g.printf("%s()\n", g.cleanups[i])
+	}
+}
+
+// printFailure writes the statements that handle a non-nil err returned by
+// obj. The builder function calls the cleanup functions returned so far and
+// returns err wrapped with the name of obj if it returns an error and panics
+// with err otherwise.
+func (g *genContext) printFailure(obj types.Object) {
+	if !g.returnsErr {
+		g.printf("panic(err)\n")
+		return
+	}
+
+	g.printCleanups()
+	results := []string{g.zeroValue(g.root)}
+	if g.returnsCleanup {
+		results = append(results, "nil")
+	}
+	results = append(results, fmt.Sprintf("%s.Errorf(\"%s: %%w\", err)",
+		g.qualify(fmtPackage), qualifiedName(obj)))
+	g.printf("return %s\n", strings.Join(results, ", "))
+}
+
+// printReturn writes the statement that returns the variable that holds the
+// root from the builder function.
+func (g *genContext) printReturn(root string) {
+	g.printf("return %s", root)
+	if g.returnsCleanup {
+		g.printf(", func() {\n")
+		g.printCleanups()
+		g.printf("}")
+	}
+	if g.returnsErr {
+		g.printf(", nil")
+	}
+	g.printf("\n")
+}
+
+// resultsString returns the source representation of the results of the
+// builder function.
+func (g *genContext) resultsString() string {
+	if !g.returnsCleanup && !g.returnsErr {
+		return g.typeString(g.root)
+	}
+
+	results := []string{g.typeString(g.root)}
+	if g.returnsCleanup {
+		results = append(results, "func()")
+	}
+	if g.returnsErr {
+		results = append(results, "error")
+	}
+	return "(" + strings.Join(results, ", ") + ")"
+}
+
+// zeroValue returns the source representation of the zero value of typ.
+func (g *genContext) zeroValue(typ types.Type) string {
+	switch underlying := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case underlying.Info()&types.IsBoolean != 0:
+			return "false"
+		case underlying.Info()&types.IsString != 0:
+			return `""`
+		case underlying.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+	case *types.Struct, *types.Array:
+		return g.typeString(typ) + "{}"
+	}
+
+	return "nil"
+}
+
+// qualify returns the name by which pkg is referred to.
+func (g *genContext) qualify(pkg *types.Package) string {
+	if g.qualifier == nil {
+		return pkg.Name()
+	}
+
+	return g.qualifier(pkg)
+}
+
+// objectString returns the (possibly package qualified) source representation
+// of the name of obj.
+func (g *genContext) objectString(obj types.Object) string {
+	if obj.Pkg() == nil || g.qualifier == nil {
+		return obj.Name()
+	}
+
+	qualifier := g.qualifier(obj.Pkg())
+	if qualifier == "" {
+		return obj.Name()
+	}
+
+	return qualifier + "." + obj.Name()
+}
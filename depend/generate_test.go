@@ -0,0 +1,87 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/token"
+	"go/types"
+	"testing"
+
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/cheekybits/is"
+)
+
+func TestGenContextObjectStringQualifiesPackageObjects(t *testing.T) {
+	is := is.New(t)
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	function := types.NewFunc(token.NoPos, pkg, "NewThing", makeSignature(nil, nil, false))
+
+	sut := newGenContext(typeutil.MakeHasher(), packageNameQualifier)
+	result := sut.objectString(function)
+
+	is.Equal(result, "mypkg.NewThing")
+}
+
+func TestGenContextObjectStringDoesNotQualifyObjectsWithoutPackage(t *testing.T) {
+	is := is.New(t)
+	function := makeFunc(nil, nil, false)
+
+	sut := newGenContext(typeutil.MakeHasher(), packageNameQualifier)
+	result := sut.objectString(function)
+
+	is.Equal(result, "myfunc")
+}
+
+func TestGenContextIsRequiredOnlyForRequiredTypes(t *testing.T) {
+	is := is.New(t)
+	required := makeNamedType("MyType", types.Typ[types.Int])
+	other := makeNamedType("MyOtherType", types.Typ[types.Int])
+
+	sut := newGenContext(typeutil.MakeHasher(), nil)
+	sut.require(required)
+
+	is.True(sut.isRequired(required))
+	is.False(sut.isRequired(other))
+}
+
+func TestGenContextVarNameIsStable(t *testing.T) {
+	is := is.New(t)
+	typ := makeNamedType("MyType", types.Typ[types.Int])
+
+	sut := newGenContext(typeutil.MakeHasher(), nil)
+	first := sut.varName(typ)
+	second := sut.varName(typ)
+
+	is.Equal(first, "myType")
+	is.Equal(first, second)
+}
+
+func TestGenContextZeroValue(t *testing.T) {
+	is := is.New(t)
+	named := makeNamedType("MyStruct", types.NewStruct(nil, nil))
+	tests := []struct {
+		expected string
+		typ      types.Type
+	}{
+		{"false", types.Typ[types.Bool]},
+		{`""`, types.Typ[types.String]},
+		{"0", types.Typ[types.Float64]},
+		{"0", makeNamedType("MyInt", types.Typ[types.Int])},
+		{"nil", types.NewPointer(named)},
+		{"nil", types.NewSlice(named)},
+		{"nil", types.NewMap(types.Typ[types.Int], named)},
+		{"nil", types.NewInterfaceType(nil, nil)},
+		{"MyStruct{}", named},
+		{"[2]int{}", types.NewArray(types.Typ[types.Int], 2)},
+	}
+
+	sut := newGenContext(typeutil.MakeHasher(), nil)
+	for _, test := range tests {
+		result := sut.zeroValue(test.typ)
+
+		is.Equal(result, test.expected)
+	}
+}
@@ -5,8 +5,15 @@
 package depend
 
 import (
+	"fmt"
+	"go/ast"
+	"go/parser"
 	"go/token"
 	"go/types"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types/typeutil"
 
 	"github.com/gonum/graph"
 )
@@ -104,3 +111,75 @@ func makeNamedType(name string, underlying types.Type) *types.Named {
 	typename := types.NewTypeName(token.NoPos, nil, name, nil)
 	return types.NewNamed(typename, underlying, nil)
 }
+
+func makeGenContext(required ...types.Type) *genContext {
+	gen := newGenContext(typeutil.MakeHasher(), packageNameQualifier)
+	for _, typ := range required {
+		gen.require(typ)
+	}
+	return gen
+}
+
+const testComponentsPath = "github.com/sbosnick/myproject/components"
+
+// loadTestPackage type checks the source of a package that imports nothing
+// except the packages in deps.
+func loadTestPackage(t *testing.T, path string, src string, deps ...*types.Package) (*types.Package, *token.FileSet) {
+	fileset := token.NewFileSet()
+	file, err := parser.ParseFile(fileset, "src.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("unable to parse test package: %v", err)
+	}
+
+	config := types.Config{Importer: packageImporter(deps)}
+	pkg, err := config.Check(path, fileset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("unable to type check test package: %v", err)
+	}
+
+	return pkg, fileset
+}
+
+// addConstructors adds each top-level function in pkg whose name starts with
+// "New" to container.
+func addConstructors(t *testing.T, container *Container, pkg *types.Package) {
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if function, ok := scope.Lookup(name).(*types.Func); ok && strings.HasPrefix(name, "New") {
+			if err := container.AddFunc(function); err != nil {
+				t.Fatalf("unable to add %s: %v", name, err)
+			}
+		}
+	}
+}
+
+// fmtSrc declares the part of the fmt package that generated code uses.
+const fmtSrc = `package fmt
+
+func Errorf(format string, a ...interface{}) error { return nil }
+`
+
+// typecheckGenerated type checks generated source code that imports
+// nothing except the packages in deps.
+func typecheckGenerated(src string, deps ...*types.Package) error {
+	fileset := token.NewFileSet()
+	file, err := parser.ParseFile(fileset, "generated.go", src, 0)
+	if err != nil {
+		return err
+	}
+
+	config := types.Config{Importer: packageImporter(deps)}
+	_, err = config.Check("github.com/sbosnick/myproject", fileset, []*ast.File{file}, nil)
+	return err
+}
+
+type packageImporter []*types.Package
+
+func (p packageImporter) Import(path string) (*types.Package, error) {
+	for _, pkg := range p {
+		if pkg.Path() == path {
+			return pkg, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown package %s", path)
+}
@@ -0,0 +1,104 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An importSet records the packages referred to by generated code and assigns
+// each one a distinct name. Objects from the package that holds the generated
+// code itself are not imported and are referred to without a qualifier.
+type importSet struct {
+	localPath string
+	names     map[string]string
+	pkgNames  map[string]string
+	used      map[string]bool
+}
+
+func newImportSet(localPath string) *importSet {
+	return &importSet{
+		localPath: localPath,
+		names:     make(map[string]string),
+		pkgNames:  make(map[string]string),
+		used:      make(map[string]bool),
+	}
+}
+
+// Qualifier returns the name by which pkg is referred to in the generated code.
+// It is a types.Qualifier.
+func (i *importSet) Qualifier(pkg *types.Package) string {
+	if pkg.Path() == i.localPath {
+		return ""
+	}
+
+	if name, ok := i.names[pkg.Path()]; ok {
+		return name
+	}
+
+	name := pkg.Name()
+	for n := 2; i.used[name]; n++ {
+		name = pkg.Name() + strconv.Itoa(n)
+	}
+
+	i.names[pkg.Path()] = name
+	i.pkgNames[pkg.Path()] = pkg.Name()
+	i.used[name] = true
+	return name
+}
+
+// Names returns the names assigned to the imported packages.
+func (i *importSet) Names() []string {
+	var names []string
+	for name := range i.used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Specs returns the import specs for the imported packages grouped into the
+// standard library packages and the other packages and sorted by path within
+// each group. The spec for a package whose assigned name differs from its
+// package name includes the assigned name.
+func (i *importSet) Specs() [][]string {
+	var paths []string
+	for path := range i.names {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var std, other []string
+	for _, path := range paths {
+		spec := strconv.Quote(path)
+		if name := i.names[path]; name != i.pkgNames[path] {
+			spec = name + " " + spec
+		}
+
+		if isStandardPath(path) {
+			std = append(std, spec)
+		} else {
+			other = append(other, spec)
+		}
+	}
+
+	var groups [][]string
+	for _, group := range [][]string{std, other} {
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// isStandardPath returns whether path is the import path of a standard
+// library package. Only such paths lack a dot in their first element.
+func isStandardPath(path string) bool {
+	first := strings.SplitN(path, "/", 2)[0]
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,69 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+func TestImportSetDoesNotQualifyLocalPackage(t *testing.T) {
+	is := is.New(t)
+	pkg := types.NewPackage("github.com/sbosnick/myproject", "main")
+
+	sut := newImportSet("github.com/sbosnick/myproject")
+	result := sut.Qualifier(pkg)
+
+	is.Equal(result, "")
+	is.Equal(len(sut.Specs()), 0)
+}
+
+func TestImportSetQualifiesWithPackageName(t *testing.T) {
+	is := is.New(t)
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+
+	sut := newImportSet("")
+	result := sut.Qualifier(pkg)
+
+	is.Equal(result, "mypkg")
+	is.Equal(sut.Specs(), [][]string{{`"github.com/sbosnick/mypkg"`}})
+}
+
+func TestImportSetRenamesCollidingPackages(t *testing.T) {
+	is := is.New(t)
+	pkg1 := types.NewPackage("github.com/sbosnick/first/log", "log")
+	pkg2 := types.NewPackage("github.com/sbosnick/second/log", "log")
+
+	sut := newImportSet("")
+	result1 := sut.Qualifier(pkg1)
+	result2 := sut.Qualifier(pkg2)
+	again := sut.Qualifier(pkg1)
+
+	is.Equal(result1, "log")
+	is.Equal(result2, "log2")
+	is.Equal(again, "log")
+	is.Equal(sut.Specs(), [][]string{{
+		`"github.com/sbosnick/first/log"`,
+		`log2 "github.com/sbosnick/second/log"`,
+	}})
+	is.Equal(sut.Names(), []string{"log", "log2"})
+}
+
+func TestImportSetGroupsStandardPackagesFirst(t *testing.T) {
+	is := is.New(t)
+	pkg := types.NewPackage("github.com/sbosnick/mypkg", "mypkg")
+	std := types.NewPackage("net/http", "http")
+
+	sut := newImportSet("")
+	sut.Qualifier(pkg)
+	sut.Qualifier(std)
+
+	is.Equal(sut.Specs(), [][]string{
+		{`"net/http"`},
+		{`"github.com/sbosnick/mypkg"`},
+	})
+}
@@ -0,0 +1,155 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+)
+
+// GraphSchemaVersion is the version of the schema of the JSON document written
+// by WriteJSON. It changes whenever a change to the schema could break an
+// existing consumer of the document.
+const GraphSchemaVersion = 1
+
+// The kinds of nodes in the JSON document written by WriteJSON.
+const (
+	constructorKind = "constructor"
+	structKind      = "struct"
+	valueKind       = "value"
+	rootKind        = "root"
+	missingKind     = "missing"
+)
+
+// WriteJSON writes the graph of the Container to w as a JSON document. The
+// document is an object with the members
+//
+//	"version": GraphSchemaVersion
+//	"nodes":   an array of node objects, ordered by "id"
+//	"edges":   an array of edge objects, ordered by "from" and then "to"
+//
+// A node object has the members
+//
+//	"id":       a number that identifies the node within the document
+//	"kind":     one of "constructor", "struct", "value", "root" or "missing"
+//	"name":     the name of the declaration or of the entry point of the root
+//	"package":  the import path of the package of the declaration
+//	"position": an object with the members "filename", "line" and "column"
+//	            for the declaration
+//	"provides": an array of the components provided by the node
+//	"requires": an array of the components required by the node
+//
+// where the declaration is the constructor for a "constructor" node, the
+// struct type added with AddStruct for a "struct" node and the variable or
+// constant added with AddVar or AddConst for a "value" node. "name" is omitted
+// for the missing node and "package" and "position" are omitted for root and
+// missing nodes. The missing node is present only if some component is
+// missing and it provides each missing component.
+//
+// An edge object has the members "from" and "to", the ids of the provider and
+// requirer, and "types", an array of the components passed along the edge.
+//
+// Components are written as Go type strings in which packages are qualified
+// by their import paths, such as "*example.com/myproject/server.Server".
+// Positions are resolved against fileSet.
+func (c *Container) WriteJSON(w io.Writer, fileSet *token.FileSet) error {
+	doc := graphJSON{
+		Version: GraphSchemaVersion,
+		Nodes:   []nodeJSON{},
+		Edges:   []edgeJSON{},
+	}
+
+	nodes := c.graphNodes()
+	for _, node := range nodes {
+		n := nodeJSON{
+			ID:       node.ID(),
+			Provides: typeStrings(node.provides()),
+			Requires: typeStrings(node.requires()),
+		}
+		switch node := node.(type) {
+		case *rootNode:
+			n.Kind = rootKind
+			n.Name = node.entryPoint()
+		case *missingNode:
+			n.Kind = missingKind
+		case *funcNode:
+			n.Kind = constructorKind
+			n.setDecl(node.object(), fileSet)
+		case *structNode:
+			n.Kind = structKind
+			n.setDecl(node.object(), fileSet)
+		case *valueNode:
+			n.Kind = valueKind
+			n.setDecl(node.object(), fileSet)
+		default:
+			panic(fmt.Sprintf("WriteJSON: unknown kind of node %T", node))
+		}
+		doc.Nodes = append(doc.Nodes, n)
+	}
+	for _, edge := range c.graphEdges(nodes) {
+		doc.Edges = append(doc.Edges, edgeJSON{
+			From:  edge.from.ID(),
+			To:    edge.to.ID(),
+			Types: typeStrings(edge.types),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+type graphJSON struct {
+	Version int        `json:"version"`
+	Nodes   []nodeJSON `json:"nodes"`
+	Edges   []edgeJSON `json:"edges"`
+}
+
+type nodeJSON struct {
+	ID       int           `json:"id"`
+	Kind     string        `json:"kind"`
+	Name     string        `json:"name,omitempty"`
+	Package  string        `json:"package,omitempty"`
+	Position *positionJSON `json:"position,omitempty"`
+	Provides []string      `json:"provides"`
+	Requires []string      `json:"requires"`
+}
+
+// setDecl sets the name, package and position of n to those of decl.
+func (n *nodeJSON) setDecl(decl types.Object, fileSet *token.FileSet) {
+	position := fileSet.Position(decl.Pos())
+	n.Name = decl.Name()
+	n.Package = decl.Pkg().Path()
+	n.Position = &positionJSON{
+		Filename: position.Filename,
+		Line:     position.Line,
+		Column:   position.Column,
+	}
+}
+
+type positionJSON struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+type edgeJSON struct {
+	From  int      `json:"from"`
+	To    int      `json:"to"`
+	Types []string `json:"types"`
+}
+
+// typeStrings returns the type strings of typs with packages qualified by
+// their import paths.
+func typeStrings(typs []types.Type) []string {
+	result := make([]string, 0, len(typs))
+	for _, typ := range typs {
+		result = append(result, ComponentString(typ, nil))
+	}
+	return result
+}
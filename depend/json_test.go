@@ -0,0 +1,77 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSONWritesVersionedGraph(t *testing.T) {
+	pkg, fileSet := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteJSON(&out, fileSet)
+
+	require.NoError(t, err)
+	var doc graphJSON
+	require.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Equal(t, GraphSchemaVersion, doc.Version)
+	require.Len(t, doc.Nodes, 4)
+	store := doc.Nodes[3]
+	assert.Equal(t, constructorKind, store.Kind)
+	assert.Equal(t, "NewStore", store.Name)
+	assert.Equal(t, testComponentsPath, store.Package)
+	require.NotNil(t, store.Position)
+	assert.Equal(t, 13, store.Position.Line)
+	assert.Equal(t, []string{"*" + testComponentsPath + ".Store"}, store.Provides)
+	assert.Equal(t, []string{testComponentsPath + ".Config"}, store.Requires)
+	root := doc.Nodes[2]
+	assert.Equal(t, rootKind, root.Kind)
+	assert.Equal(t, "Root", root.Name)
+	assert.Nil(t, root.Position)
+	assert.Contains(t, doc.Edges, edgeJSON{From: 3, To: 1, Types: []string{"*" + testComponentsPath + ".Store"}})
+}
+
+func TestWriteJSONWritesMissingNode(t *testing.T) {
+	pkg, fileSet := loadTestPackage(t, testComponentsPath, validateTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteJSON(&out, fileSet)
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `"kind": "missing"`)
+	assert.Contains(t, out.String(), `"version": 1`)
+}
+
+func TestWriteJSONWritesStructAndValueKinds(t *testing.T) {
+	pkg, fileSet := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	sut := &Container{}
+	require.NoError(t, sut.AddStruct(lookupNamed(pkg, "Server")))
+	require.NoError(t, sut.AddConst(pkg.Scope().Lookup("DefaultPort").(*types.Const)))
+
+	var out bytes.Buffer
+	err := sut.WriteJSON(&out, fileSet)
+
+	require.NoError(t, err)
+	var doc graphJSON
+	require.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+	require.Len(t, doc.Nodes, 3)
+	assert.Equal(t, structKind, doc.Nodes[0].Kind)
+	assert.Equal(t, "Server", doc.Nodes[0].Name)
+	assert.Equal(t, valueKind, doc.Nodes[2].Kind)
+	assert.Equal(t, "DefaultPort", doc.Nodes[2].Name)
+	require.NotNil(t, doc.Nodes[2].Position)
+	assert.Equal(t, 15, doc.Nodes[2].Position.Line)
+}
@@ -0,0 +1,50 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMermaid writes the graph of the Container to w as a Mermaid flowchart,
+// which renders directly in GitHub markdown. The nodes and edges are labelled
+// as for WriteDOT. Root nodes are drawn as circles, nodes for struct types as
+// rounded rectangles, nodes for values as stadiums and the node that stands in
+// for the missing providers, if any, as a hexagon.
+func (c *Container) WriteMermaid(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "flowchart TD\n")
+	nodes := c.graphNodes()
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case *rootNode:
+			fmt.Fprintf(out, "\tn%d((%s))\n", node.ID(), mermaidQuote(node.entryPoint()))
+		case *missingNode:
+			fmt.Fprintf(out, "\tn%d{{%s}}\n", node.ID(), mermaidQuote("missing"))
+		case *funcNode:
+			fmt.Fprintf(out, "\tn%d[%s]\n", node.ID(), mermaidQuote(qualifiedName(node.object())))
+		case *structNode:
+			fmt.Fprintf(out, "\tn%d(%s)\n", node.ID(), mermaidQuote(qualifiedName(node.object())))
+		case *valueNode:
+			fmt.Fprintf(out, "\tn%d([%s])\n", node.ID(), mermaidQuote(qualifiedName(node.object())))
+		default:
+			panic(fmt.Sprintf("WriteMermaid: unknown kind of node %T", node))
+		}
+	}
+	for _, edge := range c.graphEdges(nodes) {
+		fmt.Fprintf(out, "\tn%d -->|%s| n%d\n", edge.from.ID(), mermaidQuote(typeList(edge.types, "<br>")), edge.to.ID())
+	}
+
+	return out.Flush()
+}
+
+// mermaidQuote returns label as a quoted Mermaid string.
+func mermaidQuote(label string) string {
+	return `"` + strings.Replace(label, `"`, "#quot;", -1) + `"`
+}
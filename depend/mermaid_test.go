@@ -0,0 +1,66 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMermaidWritesLabelledNodesAndEdges(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteMermaid(&out)
+
+	require.NoError(t, err)
+	assert.Equal(t, `flowchart TD
+	n0["components.NewConfig"]
+	n1["components.NewServer"]
+	n2(("Root"))
+	n3["components.NewStore"]
+	n0 -->|"components.Config"| n1
+	n0 -->|"components.Config"| n3
+	n1 -->|"*components.Server"| n2
+	n3 -->|"*components.Store"| n1
+`, out.String())
+}
+
+func TestWriteMermaidWritesMissingNode(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, validateTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteMermaid(&out)
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `{{"missing"}}`)
+	assert.Contains(t, out.String(), `-->|"components.Config<br>*components.Logger"|`)
+}
+
+func TestMermaidQuoteEscapesQuotes(t *testing.T) {
+	assert.Equal(t, `"a#quot;b"`, mermaidQuote(`a"b`))
+}
+
+func TestWriteMermaidWritesStructAndValueNodes(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	sut := &Container{}
+	require.NoError(t, sut.AddStruct(lookupNamed(pkg, "Server")))
+	require.NoError(t, sut.AddConst(pkg.Scope().Lookup("DefaultPort").(*types.Const)))
+
+	var out bytes.Buffer
+	err := sut.WriteMermaid(&out)
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `n0("components.Server")`)
+	assert.Contains(t, out.String(), `n2(["components.DefaultPort"])`)
+}
@@ -28,8 +28,19 @@ func (m missingNode) ID() int {
 	return m.id
 }
 
-func (m missingNode) Generate() {
-	panic("not implemented")
+// Generate writes a declaration for each required type that has no provider.
+// Each declaration is initialized from an undefined identifier so that the
+// generated code fails to compile with an error that names the missing type.
+func (m missingNode) Generate(gen *genContext) {
+	for _, typ := range m.provides() {
+		if !gen.isRequired(typ) {
+			continue
+		}
+
+		name := gen.varName(typ)
+		gen.printf("// dibuilder: no constructor provides %s\n", gen.componentString(typ))
+		gen.printf("var %s %s = missingProviderFor_%s\n", name, gen.typeString(typ), name)
+	}
 }
 
 func (m missingNode) requires() []types.Type {
@@ -43,8 +54,8 @@ func (m missingNode) provides() []types.Type {
 
 	var result []types.Type
 
-	for _, typ := range m.container.requiredBy.Types() {
-		if len(m.container.providedBy.Nodes(typ)) == 0 {
+	for _, typ := range m.container.requiredKeys() {
+		if len(m.container.providersFor(typ)) == 0 {
 			result = append(result, typ)
 		}
 	}
@@ -56,4 +67,8 @@ func (m missingNode) getContainer() *Container {
 	return m.container
 }
 
+func (m missingNode) object() types.Object {
+	return nil
+}
+
 var _ commonNode = missingNode{}
@@ -5,6 +5,8 @@
 package depend
 
 import (
+	"go/types"
+	"strings"
 	"testing"
 
 	"github.com/cheekybits/is"
@@ -78,3 +80,31 @@ func TestNodeOfZeroContainerHsNoToNodes(t *testing.T) {
 
 	assert.Empty(t, toNodes)
 }
+
+func TestMissingNodeGenerateDeclaresUndefinedProvider(t *testing.T) {
+	is := is.New(t)
+	param := makeNamedType("MyParam", types.Typ[types.Int])
+	container := &Container{}
+	container.AddFunc(makeFunc(param, types.Typ[types.Bool], false))
+	gen := makeGenContext(param)
+
+	sut := missingNode{container: container}
+	sut.Generate(gen)
+
+	result := gen.out.String()
+	is.True(strings.Contains(result, "// dibuilder: no constructor provides MyParam\n"))
+	is.True(strings.Contains(result, "var myParam MyParam = missingProviderFor_myParam\n"))
+}
+
+func TestMissingNodeGenerateSkipsUnrequiredTypes(t *testing.T) {
+	is := is.New(t)
+	param := makeNamedType("MyParam", types.Typ[types.Int])
+	container := &Container{}
+	container.AddFunc(makeFunc(param, types.Typ[types.Bool], false))
+	gen := makeGenContext()
+
+	sut := missingNode{container: container}
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "")
+}
@@ -16,8 +16,12 @@ import (
 // the other types to provide the instances of the specific types.
 type commonNode interface {
 	graph.Node
-	Generate()
+	Generate(gen *genContext)
 	requires() []types.Type
 	provides() []types.Type
 	getContainer() *Container
+
+	// object returns the declaration from which the node was created or nil
+	// if the node was not created from a declaration.
+	object() types.Object
 }
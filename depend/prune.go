@@ -0,0 +1,60 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import "go/types"
+
+// Pruned returns the declarations of the nodes in the Container from which no
+// root can be reached, in the order they were added. These are the functions
+// that no builder function written by WriteBuilder calls. Pruned returns nil
+// if the Container has no roots.
+func (c *Container) Pruned() []types.Object {
+	_, paths := c.rootPaths()
+	reachable := reachableNodes(paths)
+	if reachable == nil {
+		return nil
+	}
+
+	var pruned []types.Object
+	for _, node := range c.nodes {
+		if obj := node.object(); obj != nil && !reachable[node.ID()] {
+			pruned = append(pruned, obj)
+		}
+	}
+	return pruned
+}
+
+// reachableNodes returns the set of IDs of the nodes from which some root can
+// be reached, given the paths from rootPaths. It returns nil if there are no
+// roots.
+func reachableNodes(paths []map[int][]commonNode) map[int]bool {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	reachable := make(map[int]bool)
+	for _, rootPaths := range paths {
+		for id := range rootPaths {
+			reachable[id] = true
+		}
+	}
+	return reachable
+}
+
+// filterNodes returns the nodes whose IDs are in reachable. It returns nodes
+// unchanged if reachable is nil.
+func filterNodes(nodes []commonNode, reachable map[int]bool) []commonNode {
+	if reachable == nil {
+		return nodes
+	}
+
+	var result []commonNode
+	for _, node := range nodes {
+		if reachable[node.ID()] {
+			result = append(result, node)
+		}
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pruneTestSrc = `package components
+
+type Config struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+type Cache struct{}
+
+type Metrics struct{}
+
+func NewServer(config Config) *Server { return nil }
+
+func NewConfig() Config { return Config{} }
+
+func NewCache(config Config, metrics *Metrics) *Cache { return nil }
+`
+
+func TestPrunedListsUnreachableConstructors(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, pruneTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	pruned := sut.Pruned()
+
+	require.Len(t, pruned, 1)
+	assert.Equal(t, "NewCache", pruned[0].Name())
+}
+
+func TestPrunedWithoutRootIsEmpty(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, cycleTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	pruned := sut.Pruned()
+
+	assert.Empty(t, pruned)
+}
+
+func TestValidateIgnoresMissingTypesOfPrunedConstructors(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, pruneTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Validate()
+
+	assert.Empty(t, errs)
+}
+
+func TestWriteBuilderOmitsPrunedConstructors(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, pruneTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.NotContains(t, out.String(), "NewCache")
+	assert.NotContains(t, out.String(), "missingProviderFor")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
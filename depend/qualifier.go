@@ -0,0 +1,140 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+// qualifierTag marks the one field of the struct type that stands for a
+// qualified component in the Container.
+const qualifierTag = `dibuilder:"qualifier"`
+
+// Qualified returns the type of the component of type typ that is qualified by
+// name. Components of the same type with different qualifiers are distinct
+// components of a Container; each of them has its own providers and its own
+// variable in the generated builder. Qualified returns typ itself if name is
+// empty.
+func Qualified(typ types.Type, name string) types.Type {
+	if name == "" {
+		return typ
+	}
+
+	// A struct type with a single field named name and tagged with
+	// qualifierTag is identical to (and hashes the same as) any other such
+	// struct type for the same typ and name and is distinct from the types
+	// that appear in the source code.
+	field := types.NewField(token.NoPos, nil, name, typ, false)
+	return types.NewStruct([]*types.Var{field}, []string{qualifierTag})
+}
+
+// Unqualified returns the type of the component typ and its qualifier. The
+// qualifier is empty if typ was not returned by Qualified.
+func Unqualified(typ types.Type) (types.Type, string) {
+	st, ok := typ.(*types.Struct)
+	if !ok || st.NumFields() != 1 || st.Tag(0) != qualifierTag {
+		return typ, ""
+	}
+
+	return st.Field(0).Type(), st.Field(0).Name()
+}
+
+// ComponentString returns the string representation of the component type typ
+// as types.TypeString does, except that a qualified component is written as
+// its type followed by its quoted qualifier, such as `*sql.DB "replica"`.
+func ComponentString(typ types.Type, qf types.Qualifier) string {
+	typ, name := Unqualified(typ)
+	if name == "" {
+		return types.TypeString(typ, qf)
+	}
+
+	return types.TypeString(typ, qf) + " " + strconv.Quote(name)
+}
+
+// A qualifiedNode is a node whose provided and required components can be
+// qualified (see Qualified).
+type qualifiedNode interface {
+	commonNode
+
+	// setName qualifies the components provided by the node by name.
+	setName(name string)
+
+	// setParamName qualifies the component required by the parameter or
+	// field named param by name. It returns ErrUnknownParam if the node has
+	// no such parameter or field and ErrParamObject if the parameter is a
+	// parameter object.
+	setParamName(param string, name string) error
+}
+
+// Name qualifies the components provided by the node created from decl by
+// name (see Qualified). A requirement for a qualified component is only
+// satisfied by a provider with the same qualifier. decl must have been added
+// to the Container, otherwise Name returns ErrNotInContainer. Name returns
+// ErrInvalidQualifier if name is not a valid Go identifier.
+func (c *Container) Name(decl types.Object, name string) error {
+	node, err := c.qualifiedNodeFor(decl)
+	if err != nil {
+		return err
+	}
+	if !token.IsIdentifier(name) {
+		return ErrInvalidQualifier
+	}
+
+	c.reindexNode(node, func() { node.setName(name) })
+	return nil
+}
+
+// NameParam qualifies the component required by the parameter or injected
+// struct field param of the node created from decl by name (see Qualified).
+// decl must have been added to the Container, otherwise NameParam returns
+// ErrNotInContainer. NameParam returns ErrInvalidQualifier if name is not a
+// valid Go identifier, ErrUnknownParam if decl has no parameter or injected
+// field named param and ErrParamObject if param is a parameter object (see
+// dibuilder.In).
+func (c *Container) NameParam(decl types.Object, param string, name string) error {
+	node, err := c.qualifiedNodeFor(decl)
+	if err != nil {
+		return err
+	}
+	if !token.IsIdentifier(name) {
+		return ErrInvalidQualifier
+	}
+
+	c.reindexNode(node, func() { err = node.setParamName(param, name) })
+	return err
+}
+
+// qualifiedNodeFor returns the node created from decl. Every node created
+// from a declaration is a qualifiedNode.
+func (c *Container) qualifiedNodeFor(decl types.Object) (qualifiedNode, error) {
+	node, ok := c.nodeFor(decl).(qualifiedNode)
+	if !ok {
+		return nil, ErrNotInContainer
+	}
+	return node, nil
+}
+
+// reindexNode applies update, which changes the components that node requires
+// or provides, and keeps the maps of the Container consistent with the change.
+func (c *Container) reindexNode(node commonNode, update func()) {
+	for _, typ := range node.provides() {
+		c.providedBy.RemoveNode(typ, node)
+	}
+	for _, typ := range node.requires() {
+		c.requiredBy.RemoveNode(typ, node)
+	}
+
+	update()
+
+	for _, typ := range node.provides() {
+		c.providedBy.AddNode(typ, node)
+	}
+	for _, typ := range node.requires() {
+		c.requiredBy.AddNode(typ, node)
+	}
+	c.resetKeys()
+}
@@ -0,0 +1,178 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const qualifierTestSrc = `package components
+
+type DB struct{}
+
+type Reports struct{}
+
+func (r *Reports) Run() {}
+
+type Store struct{}
+
+func NewPrimary() *DB { return nil }
+
+func NewReplica() (*DB, func()) { return nil, func() {} }
+
+func NewStore(db *DB) *Store { return nil }
+
+func NewReports(store *Store, db *DB) *Reports { return nil }
+`
+
+// addQualifiedConstructors adds the constructors in qualifierTestSrc to
+// container with NewPrimary and NewReplica providing the qualified components
+// primary and replica and with NewStore requiring primary and NewReports
+// requiring replica.
+func addQualifiedConstructors(t *testing.T, container *Container, pkg *types.Package) {
+	addConstructors(t, container, pkg)
+	scope := pkg.Scope()
+	require.NoError(t, container.Name(scope.Lookup("NewPrimary"), "primary"))
+	require.NoError(t, container.Name(scope.Lookup("NewReplica"), "replica"))
+	require.NoError(t, container.NameParam(scope.Lookup("NewStore"), "db", "primary"))
+	require.NoError(t, container.NameParam(scope.Lookup("NewReports"), "db", "replica"))
+}
+
+func TestQualifiedIsIdenticalForSameQualifier(t *testing.T) {
+	typ := types.NewPointer(makeNamedType("DB", types.Typ[types.Int]))
+
+	assert.True(t, types.Identical(Qualified(typ, "replica"), Qualified(typ, "replica")))
+	assert.False(t, types.Identical(Qualified(typ, "replica"), Qualified(typ, "primary")))
+	assert.False(t, types.Identical(Qualified(typ, "replica"), typ))
+	assert.Equal(t, typ, Qualified(typ, ""))
+}
+
+func TestUnqualifiedReturnsTypeAndQualifier(t *testing.T) {
+	typ := types.NewPointer(makeNamedType("DB", types.Typ[types.Int]))
+
+	unqualified, name := Unqualified(Qualified(typ, "replica"))
+	plain, empty := Unqualified(typ)
+
+	assert.Equal(t, typ, unqualified)
+	assert.Equal(t, "replica", name)
+	assert.Equal(t, typ, plain)
+	assert.Empty(t, empty)
+}
+
+func TestComponentStringIncludesQualifier(t *testing.T) {
+	typ := types.NewPointer(makeNamedType("DB", types.Typ[types.Int]))
+
+	assert.Equal(t, `*DB "replica"`, ComponentString(Qualified(typ, "replica"), nil))
+	assert.Equal(t, "*DB", ComponentString(typ, nil))
+}
+
+func TestNameOfUnknownDeclIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+
+	err := sut.Name(pkg.Scope().Lookup("NewPrimary"), "primary")
+
+	assert.Equal(t, ErrNotInContainer, err)
+}
+
+func TestNameWithInvalidQualifierIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	err := sut.Name(pkg.Scope().Lookup("NewPrimary"), "not valid")
+
+	assert.Equal(t, ErrInvalidQualifier, err)
+}
+
+func TestNameParamOfUnknownParamIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	err := sut.NameParam(pkg.Scope().Lookup("NewStore"), "conn", "primary")
+
+	assert.Equal(t, ErrUnknownParam, err)
+}
+
+func TestUnqualifiedProvidersAreAmbiguous(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 1)
+	assert.IsType(t, &AmbiguousProviderError{}, errs[0])
+}
+
+func TestQualifiedProvidersSatisfyQualifiedRequirements(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+	addQualifiedConstructors(t, sut, pkg)
+	store, _ := sut.NodeFor(pkg.Scope().Lookup("NewStore"))
+	primary, _ := sut.NodeFor(pkg.Scope().Lookup("NewPrimary"))
+	replica, _ := sut.NodeFor(pkg.Scope().Lookup("NewReplica"))
+
+	errs := sut.Validate()
+
+	assert.Empty(t, errs)
+	assert.True(t, sut.HasEdgeFromTo(primary, store))
+	assert.False(t, sut.HasEdgeFromTo(replica, store))
+}
+
+func TestMissingQualifiedComponentIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+	addQualifiedConstructors(t, sut, pkg)
+	require.NoError(t, sut.NameParam(pkg.Scope().Lookup("NewReports"), "db", "backup"))
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), `no provider for *components.DB "backup"`)
+}
+
+func TestWriteBuilderNamesQualifiedVariables(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+	addQualifiedConstructors(t, sut, pkg)
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "primaryDB := components.NewPrimary()")
+	assert.Contains(t, out.String(), "replicaDB, cleanup := components.NewReplica()")
+	assert.Contains(t, out.String(), "components.NewStore(primaryDB)")
+	assert.Contains(t, out.String(), "components.NewReports(store, replicaDB)")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestProvidesReturnsQualifiedComponents(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+	addQualifiedConstructors(t, sut, pkg)
+
+	provides, err := sut.Provides(pkg.Scope().Lookup("NewReplica"))
+
+	require.NoError(t, err)
+	require.Len(t, provides, 1)
+	assert.Equal(t, `*github.com/sbosnick/myproject/components.DB "replica"`, ComponentString(provides[0], nil))
+}
+
+func TestProvidesOfUnknownDeclIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, qualifierTestSrc)
+	sut := &Container{}
+
+	_, err := sut.Provides(pkg.Scope().Lookup("NewReplica"))
+
+	assert.Equal(t, ErrNotInContainer, err)
+}
@@ -0,0 +1,157 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"sort"
+
+	"github.com/gonum/graph"
+)
+
+// A DependencyPath is a chain of declarations along which a root of a
+// Container requires a component.
+type DependencyPath struct {
+	// EntryPoint is the name of the root, with DefaultRootName for the root
+	// that is not named.
+	EntryPoint string
+
+	// Steps are the declarations on the path, starting with the provider of
+	// the root and ending with a provider of the component. Each declaration
+	// requires a component provided by the next one.
+	Steps []PathStep
+}
+
+// A PathStep is one declaration on a DependencyPath.
+type PathStep struct {
+	// Decl is the declaration.
+	Decl types.Object
+
+	// Types are the components that Decl provides to the previous step on
+	// the path, or to the root for the first step.
+	Types []types.Type
+}
+
+// PathsFromRoot returns every path along which a root of the Container requires
+// the component typ, directly or transitively. The paths from each root are
+// found by following Container.To from the root and end at the first provider
+// of typ on each path. The paths are ordered by root and then by the order of
+// the requirements of the nodes along them. PathsFromRoot returns nil if no
+// root requires typ.
+func (c *Container) PathsFromRoot(typ types.Type) []DependencyPath {
+	targets := make(map[int]bool)
+	for _, provider := range c.providersFor(typ) {
+		targets[provider.ID()] = true
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var paths []DependencyPath
+	for _, root := range c.allRoots() {
+		var steps []PathStep
+		onPath := make(map[int]bool)
+
+		var visit func(node commonNode)
+		visit = func(node commonNode) {
+			onPath[node.ID()] = true
+			for _, provider := range c.To(node) {
+				provider := provider.(commonNode)
+				if provider == c.missingNode || onPath[provider.ID()] {
+					continue
+				}
+
+				steps = append(steps, PathStep{
+					Decl:  provider.object(),
+					Types: c.edgeTypes(provider, node),
+				})
+				if targets[provider.ID()] {
+					path := DependencyPath{EntryPoint: root.entryPoint()}
+					path.Steps = append(path.Steps, steps...)
+					paths = append(paths, path)
+				} else {
+					visit(provider)
+				}
+				steps = steps[:len(steps)-1]
+			}
+			onPath[node.ID()] = false
+		}
+		visit(root)
+	}
+
+	return paths
+}
+
+// A Dependent is a node that depends, directly or transitively, on the
+// components provided by another node.
+type Dependent struct {
+	// Node is the dependent node.
+	Node graph.Node
+
+	// Decl is the declaration of Node or nil if Node is a root node.
+	Decl types.Object
+
+	// Distance is the number of edges on the shortest path from the other
+	// node to Node. It is 1 if Node requires a component of the other node.
+	Distance int
+
+	// EntryPoints are the names of the roots that can be reached from Node,
+	// including Node itself if it is a root, with DefaultRootName for the
+	// root that is not named.
+	EntryPoints []string
+}
+
+// Dependents returns the nodes that depend on node by following Container.From
+// from node transitively. The dependents are ordered by Distance and then by
+// the IDs of their nodes. Dependents returns nil if node is not in the
+// Container.
+func (c *Container) Dependents(node graph.Node) []Dependent {
+	start, ok := node.(commonNode)
+	if !ok || !c.Has(start) {
+		return nil
+	}
+
+	distances := map[int]int{start.ID(): 0}
+	var dependents []commonNode
+	queue := []commonNode{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, requirer := range c.From(current) {
+			requirer := requirer.(commonNode)
+			if _, seen := distances[requirer.ID()]; seen {
+				continue
+			}
+			distances[requirer.ID()] = distances[current.ID()] + 1
+			dependents = append(dependents, requirer)
+			queue = append(queue, requirer)
+		}
+	}
+	sort.SliceStable(dependents, func(i, j int) bool {
+		di, dj := distances[dependents[i].ID()], distances[dependents[j].ID()]
+		if di != dj {
+			return di < dj
+		}
+		return dependents[i].ID() < dependents[j].ID()
+	})
+
+	roots, paths := c.rootPaths()
+	result := make([]Dependent, 0, len(dependents))
+	for _, dependent := range dependents {
+		d := Dependent{
+			Node:     dependent,
+			Decl:     dependent.object(),
+			Distance: distances[dependent.ID()],
+		}
+		for i, root := range roots {
+			if _, ok := paths[i][dependent.ID()]; ok {
+				d.EntryPoints = append(d.EntryPoints, root.entryPoint())
+			}
+		}
+		result = append(result, d)
+	}
+	return result
+}
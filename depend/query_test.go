@@ -0,0 +1,112 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pathNames(path DependencyPath) []string {
+	var names []string
+	for _, step := range path.Steps {
+		names = append(names, step.Decl.Name())
+	}
+	return names
+}
+
+func TestPathsFromRootReturnsEveryPath(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	paths := sut.PathsFromRoot(lookupType(pkg, "Config"))
+
+	require.Len(t, paths, 2)
+	assert.Equal(t, DefaultRootName, paths[0].EntryPoint)
+	assert.Equal(t, []string{"NewServer", "NewStore", "NewConfig"}, pathNames(paths[0]))
+	assert.Equal(t, []string{"NewServer", "NewConfig"}, pathNames(paths[1]))
+	store := paths[0].Steps[1]
+	require.Len(t, store.Types, 1)
+	assert.True(t, types.Identical(types.NewPointer(lookupType(pkg, "Store")), store.Types[0]))
+}
+
+func TestPathsFromRootStopsAtProvider(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	paths := sut.PathsFromRoot(types.NewPointer(lookupType(pkg, "Store")))
+
+	require.Len(t, paths, 1)
+	assert.Equal(t, []string{"NewServer", "NewStore"}, pathNames(paths[0]))
+}
+
+func TestPathsFromRootOfUnprovidedTypeIsEmpty(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	paths := sut.PathsFromRoot(types.Typ[types.Int])
+
+	assert.Empty(t, paths)
+}
+
+func TestPathsFromRootOfPrunedProviderIsEmpty(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, pruneTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	paths := sut.PathsFromRoot(types.NewPointer(lookupType(pkg, "Cache")))
+
+	assert.Empty(t, paths)
+}
+
+func TestDependentsAreGroupedByDistance(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	config, err := sut.NodeFor(pkg.Scope().Lookup("NewConfig"))
+	require.NoError(t, err)
+
+	dependents := sut.Dependents(config)
+
+	require.Len(t, dependents, 3)
+	assert.Equal(t, "NewServer", dependents[0].Decl.Name())
+	assert.Equal(t, 1, dependents[0].Distance)
+	assert.Equal(t, "NewStore", dependents[1].Decl.Name())
+	assert.Equal(t, 1, dependents[1].Distance)
+	assert.Nil(t, dependents[2].Decl)
+	assert.Equal(t, 2, dependents[2].Distance)
+	assert.Equal(t, []string{DefaultRootName}, dependents[2].EntryPoints)
+}
+
+func TestDependentsNameReachableEntryPoints(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, namedRootsTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddRoot("APIServer", types.NewPointer(lookupType(pkg, "Server"))))
+	require.NoError(t, sut.AddRoot("Worker", types.NewPointer(lookupType(pkg, "Worker"))))
+	store, err := sut.NodeFor(pkg.Scope().Lookup("NewStore"))
+	require.NoError(t, err)
+
+	dependents := sut.Dependents(store)
+
+	require.Len(t, dependents, 2)
+	assert.Equal(t, "NewServer", dependents[0].Decl.Name())
+	assert.Equal(t, []string{"APIServer"}, dependents[0].EntryPoints)
+}
+
+func TestNodeForUnknownDeclarationIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+
+	_, err := sut.NodeFor(pkg.Scope().Lookup("NewConfig"))
+
+	assert.Equal(t, ErrNotInContainer, err)
+}
@@ -6,15 +6,21 @@ package depend
 
 import "go/types"
 
+// DefaultRootName is the name of the entry point for the root of a Container
+// that is not a named root.
+const DefaultRootName = "Root"
+
 // A rootNode generates a code fragment to return the instance of its one
 // required type from the builder function. This type is the anchor of the
 // Container and it is expected that all other (useful) nodes will be a part
-// of the transitive closure of the requirement of this node. There should be
-// at most one rootNode in a given Container.
+// of the transitive closure of the requirement of this node. There is at
+// most one unnamed rootNode in a given Container and any number of named
+// ones, one for each entry point.
 type rootNode struct {
 	container *Container
 	id        int
 	root      types.Type
+	name      string
 }
 
 func newRootNode(container *Container, id int, root types.Type) *rootNode {
@@ -32,8 +38,18 @@ func (r rootNode) ID() int {
 	return r.id
 }
 
-func (r rootNode) Generate() {
-	panic("not implemented")
+// Generate writes the statement that returns the instance of the root type
+// from the builder function.
+func (r rootNode) Generate(gen *genContext) {
+	gen.printReturn(gen.argName(r.root))
+}
+
+// entryPoint returns the name of the entry point for the root.
+func (r rootNode) entryPoint() string {
+	if r.name == "" {
+		return DefaultRootName
+	}
+	return r.name
 }
 
 func (r rootNode) requires() []types.Type {
@@ -48,52 +64,92 @@ func (r rootNode) getContainer() *Container {
 	return r.container
 }
 
-// detectRootType returns the root Type, if any, from the slice of
-// Types provided. A Type is a root Type if its method set includes a
-// nullary method named "Run". If the provided slice of Types contains
-// more than one candidate root Type then detectRootType returns an
-// ErrAmbiguousRootDetected.
-func detectRootType(typs []types.Type) (types.Type, error) {
-	var result types.Type
+func (r rootNode) object() types.Object {
+	return nil
+}
 
-	for _, typ := range typs {
-		if isRunnableType(typ) {
-			if result != nil {
-				return nil, ErrAmbiguousRootDetected
-			}
+// A RootMethod describes the signature of a method that marks the type whose
+// method set includes it as a root type. Params and Results are the type
+// strings, with packages qualified by their import path, of the parameters and
+// results of the method.
+type RootMethod struct {
+	Name    string
+	Params  []string
+	Results []string
+}
 
-			result = typ
-		}
+// DefaultRootMethods are the root methods of a Container for which
+// SetRootMethods has not been called. They are "Run()" and
+// "Run(context.Context) error".
+var DefaultRootMethods = []RootMethod{
+	{Name: "Run"},
+	{Name: "Run", Params: []string{"context.Context"}, Results: []string{"error"}},
+}
+
+// matches returns whether function has the signature described by m.
+func (m RootMethod) matches(function *types.Func) bool {
+	if function.Name() != m.Name {
+		return false
 	}
 
-	return result, nil
+	sig, ok := function.Type().(*types.Signature)
+	if !ok || sig.Variadic() {
+		return false
+	}
+
+	return tupleMatches(sig.Params(), m.Params) && tupleMatches(sig.Results(), m.Results)
 }
 
-func isRunnableType(typ types.Type) bool {
-	methods := types.NewMethodSet(typ)
+func tupleMatches(tuple *types.Tuple, typeStrings []string) bool {
+	if tuple.Len() != len(typeStrings) {
+		return false
+	}
 
-	for i := 0; i < methods.Len(); i++ {
-		if function, ok := methods.At(i).Obj().(*types.Func); ok {
-			if isNullaryRunMethod(function) {
-				return true
-			}
+	for i := 0; i < tuple.Len(); i++ {
+		if types.TypeString(tuple.At(i).Type(), nil) != typeStrings[i] {
+			return false
 		}
 	}
 
-	return false
+	return true
 }
 
-func isNullaryRunMethod(function *types.Func) bool {
-	if function.Name() != "Run" {
-		return false
+// detectRootTypes returns the root Types, if any, from the slice of
+// Types provided. A Type is a root Type if its method set includes a
+// method that matches one of methods.
+func detectRootTypes(typs []types.Type, methods []RootMethod) []types.Type {
+	var result []types.Type
+
+	for _, typ := range typs {
+		if isRunnableType(typ, methods) {
+			result = append(result, typ)
+		}
 	}
 
-	sig, ok := function.Type().(*types.Signature)
-	if !ok {
-		return false
+	return result
+}
+
+// A rootCandidate is an auto-detected root type and the declaration that
+// provides it.
+type rootCandidate struct {
+	root types.Type
+	decl types.Object
+}
+
+func isRunnableType(typ types.Type, methods []RootMethod) bool {
+	methodSet := types.NewMethodSet(typ)
+
+	for i := 0; i < methodSet.Len(); i++ {
+		if function, ok := methodSet.At(i).Obj().(*types.Func); ok {
+			for _, method := range methods {
+				if method.matches(function) {
+					return true
+				}
+			}
+		}
 	}
 
-	return sig.Params().Len() == 0 && sig.Results().Len() == 0
+	return false
 }
 
 var _ commonNode = rootNode{}
@@ -203,21 +203,19 @@ func TestRootedContainerEdgeHoldsExpectedFromAndTo(t *testing.T) {
 	assert.Equal(t, root.ID(), edge.To().ID(), "Unexpected To node in the Edge")
 }
 
-func TestDetectRootTypeIsNilForNonRunnableTypes(t *testing.T) {
-	is := is.New(t)
+func TestDetectRootTypesIsEmptyForNonRunnableTypes(t *testing.T) {
 	typ := types.Typ[types.Int]
 
-	result, err := detectRootType([]types.Type{typ})
+	result := detectRootTypes([]types.Type{typ}, DefaultRootMethods)
 
-	is.NoErr(err)
-	is.Nil(result)
+	assert.Empty(t, result)
 }
 
 func TestTypeWithoutMethodsIsNotRunnable(t *testing.T) {
 	is := is.New(t)
 	typ := types.Typ[types.Int]
 
-	result := isRunnableType(typ)
+	result := isRunnableType(typ, DefaultRootMethods)
 
 	is.False(result)
 }
@@ -229,7 +227,7 @@ func TestInterfaceWithoutRunIsNotRunnable(t *testing.T) {
 	typ := types.NewInterface([]*types.Func{function}, nil)
 	typ.Complete()
 
-	result := isRunnableType(typ)
+	result := isRunnableType(typ, DefaultRootMethods)
 
 	is.False(result)
 }
@@ -241,7 +239,7 @@ func TestInterfaceWithNonNullaryRunIsNotRunnable(t *testing.T) {
 	typ := types.NewInterface([]*types.Func{function}, nil)
 	typ.Complete()
 
-	result := isRunnableType(typ)
+	result := isRunnableType(typ, DefaultRootMethods)
 
 	is.False(result)
 }
@@ -253,7 +251,7 @@ func TestInterfaceWithNullaryRunIsRunnable(t *testing.T) {
 	typ := types.NewInterface([]*types.Func{function}, nil)
 	typ.Complete()
 
-	result := isRunnableType(typ)
+	result := isRunnableType(typ, DefaultRootMethods)
 
 	is.True(result)
 }
@@ -263,7 +261,7 @@ func TestNamedWithoutMethodsIsNotRunnable(t *testing.T) {
 	typename := types.NewTypeName(token.NoPos, nil, "MyIntType", nil)
 	name := types.NewNamed(typename, types.Typ[types.Int], nil)
 
-	result := isRunnableType(name)
+	result := isRunnableType(name, DefaultRootMethods)
 
 	is.False(result)
 }
@@ -280,7 +278,7 @@ func TestNamedWithoutRunIsNotRunnable(t *testing.T) {
 	function := types.NewFunc(token.NoPos, nil, "NotRun", sig)
 	named.AddMethod(function)
 
-	result := isRunnableType(named)
+	result := isRunnableType(named, DefaultRootMethods)
 
 	is.False(result)
 }
@@ -297,7 +295,7 @@ func TestNamedWithNonNullaryRunIsNotRunnable(t *testing.T) {
 	function := types.NewFunc(token.NoPos, nil, "Run", sig)
 	named.AddMethod(function)
 
-	result := isRunnableType(named)
+	result := isRunnableType(named, DefaultRootMethods)
 
 	is.False(result)
 }
@@ -314,27 +312,118 @@ func TestNamedWithNullaryRunIsRunnable(t *testing.T) {
 	function := types.NewFunc(token.NoPos, nil, "Run", sig)
 	named.AddMethod(function)
 
-	result := isRunnableType(named)
+	result := isRunnableType(named, DefaultRootMethods)
 
 	is.True(result)
 }
 
-func TestDetectRootTypeReturnsRunnableType(t *testing.T) {
-	is := is.New(t)
+func TestDetectRootTypesReturnsRunnableType(t *testing.T) {
 	type1 := makeRunnableType("Type1")
 	type2 := types.Typ[types.Int]
 
-	typ, _ := detectRootType([]types.Type{type1, type2})
+	result := detectRootTypes([]types.Type{type1, type2}, DefaultRootMethods)
 
-	is.OK(typ)
+	assert.Equal(t, []types.Type{type1}, result)
 }
 
-func TestDetectRootTypeWithMultipleRunnableTypesIsError(t *testing.T) {
-	is := is.New(t)
+func TestDetectRootTypesReturnsMultipleRunnableTypes(t *testing.T) {
 	type1 := makeRunnableType("Type1")
 	type2 := makeRunnableType("Type2")
 
-	_, err := detectRootType([]types.Type{type1, type2})
+	result := detectRootTypes([]types.Type{type1, type2}, DefaultRootMethods)
 
-	is.Err(err)
+	assert.Equal(t, []types.Type{type1, type2}, result)
+}
+
+func TestRootNodeGenerateReturnsRootVariable(t *testing.T) {
+	is := is.New(t)
+	roottype := makeNamedType("MyRoot", types.Typ[types.Int])
+	gen := makeGenContext(roottype)
+
+	sut := newRootNode(nil, 0, roottype)
+	sut.Generate(gen)
+
+	is.Equal(gen.out.String(), "return myRoot\n")
+}
+
+func TestNamedWithContextRunIsRunnable(t *testing.T) {
+	is := is.New(t)
+	named := makeNamedWithRunMethod("Server",
+		[]types.Type{makeContextType()},
+		[]types.Type{types.Universe.Lookup("error").Type()})
+
+	result := isRunnableType(named, DefaultRootMethods)
+
+	is.True(result)
+}
+
+func TestNamedWithContextRunWithoutErrorIsNotRunnable(t *testing.T) {
+	is := is.New(t)
+	named := makeNamedWithRunMethod("Server", []types.Type{makeContextType()}, nil)
+
+	result := isRunnableType(named, DefaultRootMethods)
+
+	is.False(result)
+}
+
+func TestNamedWithCustomRootMethodIsRunnable(t *testing.T) {
+	is := is.New(t)
+	named := makeNamedWithRunMethod("Server", nil, []types.Type{types.Typ[types.Int]})
+	methods := []RootMethod{{Name: "Run", Results: []string{"int"}}}
+
+	result := isRunnableType(named, methods)
+
+	is.True(result)
+}
+
+func TestNamedWithNullaryRunIsNotRunnableForOtherRootMethods(t *testing.T) {
+	is := is.New(t)
+	named := makeNamedWithRunMethod("Server", nil, nil)
+	methods := []RootMethod{{Name: "Serve"}}
+
+	result := isRunnableType(named, methods)
+
+	is.False(result)
+}
+
+func TestContainerWithRootMethodsDetectsMatchingRoot(t *testing.T) {
+	is := is.New(t)
+	named := makeNamedWithRunMethod("Server", nil, nil)
+	serve := types.NewFunc(token.NoPos, nil, "Serve", types.NewSignature(
+		types.NewParam(token.NoPos, nil, "s", named), nil, nil, false))
+	named.AddMethod(serve)
+	sut := &Container{}
+	sut.SetRootMethods(RootMethod{Name: "Serve"})
+
+	err := sut.AddFunc(makeFunc(nil, named, false))
+	root, rootErr := sut.Root()
+
+	is.NoErr(err)
+	is.NoErr(rootErr)
+	is.Equal(root.(*rootNode).root, named)
+}
+
+func makeContextType() types.Type {
+	pkg := types.NewPackage("context", "context")
+	typename := types.NewTypeName(token.NoPos, pkg, "Context", nil)
+	return types.NewNamed(typename, types.NewInterfaceType(nil, nil).Complete(), nil)
+}
+
+func makeNamedWithRunMethod(name string, params, results []types.Type) *types.Named {
+	named := makeNamedType(name, types.NewStruct(nil, nil))
+	sig := types.NewSignature(
+		types.NewParam(token.NoPos, nil, "s", named),
+		makeTuple(params),
+		makeTuple(results),
+		false)
+	named.AddMethod(types.NewFunc(token.NoPos, nil, "Run", sig))
+	return named
+}
+
+func makeTuple(typs []types.Type) *types.Tuple {
+	vars := make([]*types.Var, 0, len(typs))
+	for _, typ := range typs {
+		vars = append(vars, types.NewParam(token.NoPos, nil, "", typ))
+	}
+	return types.NewTuple(vars...)
 }
@@ -0,0 +1,185 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"reflect"
+)
+
+// injectTag is the value of the "dibuilder" key in the tag of a struct field
+// that marks the field as one to inject.
+const injectTag = "inject"
+
+// A structNode generates a code fragment to produce instances of a named
+// struct type with a composite literal. Its required types are the types of
+// the injected fields of the struct and its provided types are the struct
+// type and the pointer to it. The injected fields are the fields tagged with
+// `dibuilder:"inject"` or, if no field is so tagged, all of the exported
+// fields. A structNode for a named struct type provides components qualified
+// by its name and requires the components of the fields in fieldNames
+// qualified by their names (see Qualified).
+type structNode struct {
+	container  *Container
+	id         int
+	named      *types.Named
+	fields     []*types.Var
+	name       string
+	fieldNames map[int]string
+}
+
+func newStructNode(container *Container, id int, named *types.Named) (*structNode, error) {
+	// Check for a type that the builder cannot refer to.
+	if !named.Obj().Exported() {
+		return nil, newInvalidDeclError(named.Obj(), "cannot add unexported types to a Container")
+	}
+
+	// Check for a generic type.
+	if named.TypeParams().Len() > 0 {
+		return nil, newInvalidDeclError(named.Obj(), "cannot add generic types to a Container")
+	}
+
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, newInvalidDeclError(named.Obj(), "not a struct type")
+	}
+
+	var tagged, exported []*types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if reflect.StructTag(st.Tag(i)).Get("dibuilder") == injectTag {
+			if !field.Exported() {
+				return nil, newInvalidDeclError(named.Obj(), "cannot inject unexported field "+field.Name())
+			}
+			tagged = append(tagged, field)
+		}
+		if field.Exported() {
+			exported = append(exported, field)
+		}
+	}
+
+	node := &structNode{
+		container: container,
+		id:        id,
+		named:     named,
+		fields:    tagged,
+	}
+	if len(tagged) == 0 {
+		node.fields = exported
+	}
+	for _, field := range node.fields {
+		if !isExportedType(field.Type()) {
+			return nil, newInvalidDeclError(named.Obj(), "cannot inject field "+field.Name()+" of unexported type")
+		}
+	}
+	return node, nil
+}
+
+// isExportedType returns whether every named type that makes up typ is
+// exported or predeclared, so that code in another package can refer to typ.
+func isExportedType(typ types.Type) bool {
+	switch typ := typ.(type) {
+	case *types.Named:
+		obj := typ.Obj()
+		if obj.Pkg() != nil && !obj.Exported() {
+			return false
+		}
+		args := typ.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if !isExportedType(args.At(i)) {
+				return false
+			}
+		}
+		return true
+	case *types.Pointer:
+		return isExportedType(typ.Elem())
+	case *types.Slice:
+		return isExportedType(typ.Elem())
+	case *types.Array:
+		return isExportedType(typ.Elem())
+	case *types.Chan:
+		return isExportedType(typ.Elem())
+	case *types.Map:
+		return isExportedType(typ.Key()) && isExportedType(typ.Elem())
+	}
+
+	return true
+}
+
+func (s structNode) ID() int {
+	if s.id < 0 {
+		panic("Non singleton nodes cannot have a negative id.")
+	}
+	return s.id
+}
+
+// Generate writes a composite literal of the struct type that sets each of the
+// injected fields to the variable for the field's type. The literal is
+// assigned to the variable for the struct type, to the variable for the
+// pointer to the struct type (as the address of the literal), or to both if
+// both are required.
+func (s structNode) Generate(gen *genContext) {
+	literal := gen.compositeLiteral(s.named, s.fields, s.requires())
+
+	value := Qualified(s.named, s.name)
+	ptr := Qualified(types.NewPointer(s.named), s.name)
+	switch {
+	case gen.isRequired(value) && gen.isRequired(ptr):
+		name := gen.varName(value)
+		gen.printf("%s := %s\n", name, literal)
+		gen.printf("%s := &%s\n", gen.varName(ptr), name)
+	case gen.isRequired(ptr):
+		gen.printf("%s := &%s\n", gen.varName(ptr), literal)
+	case gen.isRequired(value):
+		gen.printf("%s := %s\n", gen.varName(value), literal)
+	}
+}
+
+func (s structNode) requires() []types.Type {
+	var result []types.Type
+	for i, field := range s.fields {
+		result = append(result, Qualified(field.Type(), s.fieldNames[i]))
+	}
+	return result
+}
+
+func (s structNode) provides() []types.Type {
+	return []types.Type{
+		Qualified(s.named, s.name),
+		Qualified(types.NewPointer(s.named), s.name),
+	}
+}
+
+// setName qualifies the components provided by the struct type by name.
+func (s *structNode) setName(name string) {
+	s.name = name
+}
+
+// setParamName qualifies the component required by the injected field named
+// param by name. It returns ErrUnknownParam if there is no such field.
+func (s *structNode) setParamName(param string, name string) error {
+	for i, field := range s.fields {
+		if field.Name() == param {
+			if s.fieldNames == nil {
+				s.fieldNames = make(map[int]string)
+			}
+			s.fieldNames[i] = name
+			return nil
+		}
+	}
+
+	return ErrUnknownParam
+}
+
+func (s structNode) getContainer() *Container {
+	return s.container
+}
+
+func (s structNode) object() types.Object {
+	return s.named.Obj()
+}
+
+var _ commonNode = structNode{}
+var _ qualifiedNode = &structNode{}
@@ -0,0 +1,173 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const structTestSrc = `package components
+
+type Config struct{}
+
+type Logger struct{}
+
+type Handler struct {
+	Config Config
+	Logger *Logger
+	cache  map[string]string
+}
+
+type Server struct {
+	Handler *Handler ` + "`dibuilder:\"inject\"`" + `
+	Port    int
+}
+
+func (s Server) Run() {}
+
+type Hidden struct {
+	logger *Logger ` + "`dibuilder:\"inject\"`" + `
+}
+
+type Generic[T any] struct {
+	Value T
+}
+
+type NotStruct int
+
+type hidden struct{}
+
+type cache struct{}
+
+type Cached struct {
+	Cache *cache
+}
+
+func NewConfig() Config { return Config{} }
+
+func NewLogger() *Logger { return nil }
+`
+
+func lookupNamed(pkg *types.Package, name string) *types.Named {
+	return pkg.Scope().Lookup(name).Type().(*types.Named)
+}
+
+func TestStructNodeWithNegativeIDPanicsOnID(t *testing.T) {
+	sut := structNode{id: -1}
+
+	assert.Panics(t, func() { sut.ID() }, "Negative ID did not panic")
+}
+
+func TestStructNodeRequiresExportedFields(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, structTestSrc)
+	handler := lookupNamed(pkg, "Handler")
+
+	sut, err := newStructNode(nil, 0, handler)
+
+	require.NoError(t, err)
+	requires := sut.requires()
+	require.Len(t, requires, 2)
+	assert.Equal(t, "Config", requires[0].(*types.Named).Obj().Name())
+	assert.Equal(t, "*github.com/sbosnick/myproject/components.Logger", requires[1].String())
+}
+
+func TestStructNodeRequiresOnlyTaggedFields(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, structTestSrc)
+	server := lookupNamed(pkg, "Server")
+
+	sut, err := newStructNode(nil, 0, server)
+
+	require.NoError(t, err)
+	requires := sut.requires()
+	require.Len(t, requires, 1)
+	assert.True(t, types.Identical(types.NewPointer(lookupNamed(pkg, "Handler")), requires[0]))
+}
+
+func TestStructNodeProvidesTypeAndPointer(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, structTestSrc)
+	handler := lookupNamed(pkg, "Handler")
+
+	sut, err := newStructNode(nil, 0, handler)
+
+	require.NoError(t, err)
+	provides := sut.provides()
+	require.Len(t, provides, 2)
+	assert.True(t, types.Identical(handler, provides[0]))
+	assert.True(t, types.Identical(types.NewPointer(handler), provides[1]))
+}
+
+func TestAddStructOfInvalidTypeIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, structTestSrc)
+
+	for _, name := range []string{"Hidden", "Generic", "NotStruct", "hidden", "Cached"} {
+		sut := &Container{}
+
+		err := sut.AddStruct(lookupNamed(pkg, name))
+
+		assert.IsType(t, &InvalidDeclError{}, err, name)
+	}
+}
+
+func TestAddStructDetectsOneRoot(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, structTestSrc)
+	sut := &Container{}
+
+	err := sut.AddStruct(lookupNamed(pkg, "Server"))
+
+	require.NoError(t, err)
+	assert.Nil(t, sut.ambiguousRoot())
+	root, err := sut.Root()
+	require.NoError(t, err)
+	assert.True(t, types.Identical(lookupNamed(pkg, "Server"), root.(*rootNode).root))
+}
+
+func TestWriteBuilderPopulatesStructs(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, structTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddStruct(lookupNamed(pkg, "Handler")))
+	require.NoError(t, sut.AddStruct(lookupNamed(pkg, "Server")))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "handler := &components.Handler{\n\t\tConfig: config,\n\t\tLogger: logger,\n\t}")
+	assert.Contains(t, out.String(), "server := components.Server{\n\t\tHandler: handler,\n\t}")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestWriteBuilderQualifiesStructs(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, structTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddStruct(lookupNamed(pkg, "Handler")))
+	require.NoError(t, sut.AddStruct(lookupNamed(pkg, "Server")))
+	require.NoError(t, sut.Name(pkg.Scope().Lookup("Handler"), "main"))
+	require.NoError(t, sut.NameParam(pkg.Scope().Lookup("Server"), "Handler", "main"))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "mainHandler := &components.Handler{")
+	assert.Contains(t, out.String(), "Handler: mainHandler,")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestNameParamOfUninjectedFieldIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, structTestSrc)
+	sut := &Container{}
+	require.NoError(t, sut.AddStruct(lookupNamed(pkg, "Server")))
+
+	err := sut.NameParam(pkg.Scope().Lookup("Server"), "Port", "main")
+
+	assert.Equal(t, ErrUnknownParam, err)
+}
@@ -35,6 +35,30 @@ func (m *typeNodeMap) AddNode(typ types.Type, n commonNode) {
 	m.typeMap.Set(typ, nodes)
 }
 
+func (m *typeNodeMap) RemoveNode(typ types.Type, n commonNode) {
+	if m == nil {
+		return
+	}
+
+	result := m.typeMap.At(typ)
+	if result == nil {
+		return
+	}
+
+	var nodes []commonNode
+	for _, node := range result.([]commonNode) {
+		if node != n {
+			nodes = append(nodes, node)
+		}
+	}
+
+	if len(nodes) == 0 {
+		m.typeMap.Delete(typ)
+	} else {
+		m.typeMap.Set(typ, nodes)
+	}
+}
+
 func (m *typeNodeMap) Nodes(typ types.Type) []commonNode {
 	if m == nil {
 		var ret []commonNode
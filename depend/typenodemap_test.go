@@ -75,3 +75,28 @@ func TestTypeNodeMapGivesEmptyNodesForAbsentType(t *testing.T) {
 
 	assert.Len(t, nodes, 0, "Node unexpectedly not 0 length")
 }
+
+func TestTypeNodeMapRemoveNodeRemovesOnlyThatNode(t *testing.T) {
+	node1 := &funcNode{id: 1}
+	node2 := &funcNode{id: 2}
+	typ := types.Typ[types.Int]
+
+	sut := newTypeNodeMap(typeutil.MakeHasher())
+	sut.AddNode(typ, node1)
+	sut.AddNode(typ, node2)
+	sut.RemoveNode(typ, node1)
+	nodes := sut.Nodes(typ)
+
+	assert.Equal(t, []commonNode{node2}, nodes)
+}
+
+func TestTypeNodeMapRemoveLastNodeRemovesType(t *testing.T) {
+	node := &funcNode{}
+	typ := types.Typ[types.Int]
+
+	sut := newTypeNodeMap(typeutil.MakeHasher())
+	sut.AddNode(typ, node)
+	sut.RemoveNode(typ, node)
+
+	assert.Empty(t, sut.Types())
+}
@@ -0,0 +1,55 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+type typeSet struct {
+	typeMap typeutil.Map
+}
+
+func newTypeSet(hasher typeutil.Hasher) *typeSet {
+	ts := typeSet{}
+	ts.typeMap.SetHasher(hasher)
+	return &ts
+}
+
+func (s *typeSet) Add(typ types.Type) {
+	if s == nil {
+		panic("Add called on a nil typeSet.")
+	}
+
+	s.typeMap.Set(typ, true)
+}
+
+func (s *typeSet) Has(typ types.Type) bool {
+	if s == nil {
+		return false
+	}
+
+	return s.typeMap.At(typ) != nil
+}
+
+func (s *typeSet) Types() []types.Type {
+	if s == nil {
+		var ret []types.Type
+		return ret
+	}
+
+	return s.typeMap.Keys()
+}
+
+// sortTypes sorts typs by their package path qualified names and returns typs.
+func sortTypes(typs []types.Type) []types.Type {
+	sort.Slice(typs, func(i, j int) bool {
+		return types.TypeString(typs[i], nil) < types.TypeString(typs[j], nil)
+	})
+	return typs
+}
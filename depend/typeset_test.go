@@ -0,0 +1,42 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"testing"
+
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/cheekybits/is"
+)
+
+func TestNilTypeSetHasNothing(t *testing.T) {
+	is := is.New(t)
+
+	var sut *typeSet
+	result := sut.Has(types.Typ[types.Int])
+
+	is.False(result)
+}
+
+func TestAddNilTypeSetPanics(t *testing.T) {
+	is := is.New(t)
+
+	var sut *typeSet
+	is.Panic(func() { sut.Add(types.Typ[types.Int]) })
+}
+
+func TestTypeSetHasAddedType(t *testing.T) {
+	is := is.New(t)
+	typ := types.Typ[types.Int]
+
+	sut := newTypeSet(typeutil.MakeHasher())
+	sut.Add(typ)
+
+	is.True(sut.Has(typ))
+	is.False(sut.Has(types.Typ[types.Bool]))
+	is.Equal(len(sut.Types()), 1)
+}
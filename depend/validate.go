@@ -0,0 +1,162 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import "go/types"
+
+// Validate checks that the part of the Container from which its roots can be
+// reached is complete and that it can be ordered. Nodes from which no root can
+// be reached are pruned (see Pruned) and do not give rise to errors unless the
+// Container has no roots, in which case the whole Container is checked. It
+// returns an AmbiguousRootError if the root of the Container is ambiguous,
+// followed by a MissingDependencyError for each component that is required by
+// some node in the Container but is not provided by any node, followed by an
+// AmbiguousProviderError for each required component that is provided by more
+// than one node, followed by the errors from Cycles for the cycles from which
+// a root can be reached. The errors for components are sorted by the name of
+// the component. If the Container has named roots, each error for a component
+// names the entry points from which the nodes that require it can be reached.
+func (c *Container) Validate() []Error {
+	var errs []Error
+	if err := c.ambiguousRoot(); err != nil {
+		errs = append(errs, err)
+	}
+
+	c.ensureMissingNode()
+	roots, paths := c.rootPaths()
+	reachable := reachableNodes(paths)
+
+	for _, typ := range c.missingNode.provides() {
+		requirers := filterNodes(c.requirersOf(typ), reachable)
+		if len(requirers) == 0 {
+			continue
+		}
+
+		err := &MissingDependencyError{Type: typ}
+		var shortest []commonNode
+		for _, requirer := range requirers {
+			if _, ok := requirer.(*rootNode); ok {
+				err.RequiredByRoot = true
+				continue
+			}
+			if obj := requirer.object(); obj != nil {
+				err.RequiredBy = append(err.RequiredBy, obj)
+			}
+			for _, rootPaths := range paths {
+				if path, ok := rootPaths[requirer.ID()]; ok && (shortest == nil || len(path) < len(shortest)) {
+					shortest = path
+				}
+			}
+		}
+		err.Path = pathObjects(shortest)
+		err.EntryPoints = c.entryPoints(roots, paths, requirers)
+
+		errs = append(errs, err)
+	}
+
+	for _, typ := range c.requiredKeys() {
+		requirers := filterNodes(c.requirersOf(typ), reachable)
+		if len(requirers) > 0 && len(c.providersFor(typ)) > 1 {
+			err := c.newAmbiguousProviderError(typ, requirers)
+			err.EntryPoints = c.entryPoints(roots, paths, requirers)
+			errs = append(errs, err)
+		}
+	}
+
+	for _, err := range c.Cycles() {
+		node := c.nodeFor(err.(*CycleError).Cycle[0])
+		if reachable == nil || (node != nil && reachable[node.ID()]) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// newAmbiguousProviderError returns the AmbiguousProviderError for typ, which
+// is required by requirers.
+func (c *Container) newAmbiguousProviderError(typ types.Type, requirers []commonNode) *AmbiguousProviderError {
+	err := &AmbiguousProviderError{Type: typ}
+	for _, provider := range c.providersFor(typ) {
+		if obj := provider.object(); obj != nil {
+			err.Providers = append(err.Providers, obj)
+		}
+	}
+	for _, requirer := range requirers {
+		if obj := requirer.object(); obj != nil {
+			err.RequiredBy = append(err.RequiredBy, obj)
+		}
+	}
+	return err
+}
+
+// pathsFromRoot returns the shortest chain of nodes from root to each node
+// from which root can be reached. Each chain starts with a provider of a
+// requirement of root and ends with the node itself.
+func (c *Container) pathsFromRoot(root commonNode) map[int][]commonNode {
+	paths := map[int][]commonNode{root.ID(): nil}
+	queue := []commonNode{root}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, provider := range c.To(node) {
+			provider := provider.(commonNode)
+			if _, seen := paths[provider.ID()]; seen || provider == c.missingNode {
+				continue
+			}
+
+			path := make([]commonNode, len(paths[node.ID()]), len(paths[node.ID()])+1)
+			copy(path, paths[node.ID()])
+			paths[provider.ID()] = append(path, provider)
+			queue = append(queue, provider)
+		}
+	}
+
+	return paths
+}
+
+// rootPaths returns the roots of the Container and, for each of them, the
+// paths returned by pathsFromRoot.
+func (c *Container) rootPaths() ([]*rootNode, []map[int][]commonNode) {
+	roots := c.allRoots()
+	paths := make([]map[int][]commonNode, len(roots))
+	for i, root := range roots {
+		paths[i] = c.pathsFromRoot(root)
+	}
+	return roots, paths
+}
+
+// entryPoints returns the names of the roots from which any of nodes can be
+// reached, given the paths from rootPaths. It returns nil if the Container has
+// no named roots.
+func (c *Container) entryPoints(roots []*rootNode, paths []map[int][]commonNode, nodes []commonNode) []string {
+	if len(c.namedRoots) == 0 {
+		return nil
+	}
+
+	var names []string
+	for i, root := range roots {
+		for _, node := range nodes {
+			if _, ok := paths[i][node.ID()]; ok {
+				names = append(names, root.entryPoint())
+				break
+			}
+		}
+	}
+	return names
+}
+
+// pathObjects returns the declarations of the nodes in path.
+func pathObjects(path []commonNode) []types.Object {
+	var objs []types.Object
+	for _, node := range path {
+		if obj := node.object(); obj != nil {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
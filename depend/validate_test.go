@@ -0,0 +1,167 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validateTestSrc = `package components
+
+type Config struct{}
+
+type Logger struct{}
+
+type Store struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+func NewServer(store *Store) *Server { return nil }
+
+func NewStore(config Config, logger *Logger) *Store { return nil }
+
+func NewCache(config Config) *int { return nil }
+`
+
+func TestValidateOfCompleteContainerIsEmpty(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, builderTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Validate()
+
+	assert.Empty(t, errs)
+}
+
+func TestValidateReportsEachMissingType(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, validateTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 2)
+	logger := errs[0].(*MissingDependencyError)
+	config := errs[1].(*MissingDependencyError)
+	assert.Equal(t, pkg.Scope().Lookup("Config").Type(), config.Type)
+	require.Len(t, config.RequiredBy, 1, "NewCache is pruned")
+	assert.Equal(t, "NewStore", config.RequiredBy[0].Name())
+	assert.Len(t, logger.RequiredBy, 1)
+	assert.Equal(t, "NewStore", logger.RequiredBy[0].Name())
+}
+
+func TestValidateReportsPathFromRoot(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, validateTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 2)
+	path := errs[0].(*MissingDependencyError).Path
+	require.Len(t, path, 2)
+	assert.Equal(t, "NewServer", path[0].Name())
+	assert.Equal(t, "NewStore", path[1].Name())
+}
+
+func TestValidateReportsMissingRoot(t *testing.T) {
+	sut, typ := createRootedContainer()
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 1)
+	err := errs[0].(*MissingDependencyError)
+	assert.Equal(t, typ, err.Type)
+	assert.True(t, err.RequiredByRoot)
+	assert.Empty(t, err.RequiredBy)
+	assert.Empty(t, err.Path)
+}
+
+func TestValidateReportsAmbiguousProviders(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, ambiguousTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 1)
+	err := errs[0].(*AmbiguousProviderError)
+	assert.Len(t, err.Providers, 2)
+	require.Len(t, err.RequiredBy, 1)
+	assert.Equal(t, "NewApp", err.RequiredBy[0].Name())
+}
+
+func TestValidateAcceptsPreferredProvider(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, ambiguousTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+
+	_ = sut.Prefer(pkg.Scope().Lookup("NewLogger"))
+	errs := sut.Validate()
+
+	assert.Empty(t, errs)
+}
+
+func TestValidateNamesEntryPointsOfErrors(t *testing.T) {
+	src := `package components
+
+type Config struct{}
+
+type Store struct{}
+
+type Server struct{}
+
+type Worker struct{}
+
+type Migrator struct{}
+
+func NewServer(store *Store) *Server { return nil }
+
+func NewWorker(store *Store) *Worker { return nil }
+
+func NewMigrator(config Config) *Migrator { return nil }
+
+func NewStore(config Config) *Store { return nil }
+`
+	pkg, _ := loadTestPackage(t, testComponentsPath, src)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	for _, name := range []string{"Server", "Worker", "Migrator"} {
+		typ := types.NewPointer(pkg.Scope().Lookup(name).Type())
+		require.NoError(t, sut.AddRoot(name, typ))
+	}
+
+	errs := sut.Validate()
+
+	require.Len(t, errs, 1)
+	err := errs[0].(*MissingDependencyError)
+	assert.Equal(t, []string{"Server", "Worker", "Migrator"}, err.EntryPoints)
+	assert.Contains(t, err.Error(), "(entry points: Server, Worker, Migrator)")
+}
+
+func TestCyclesNameEntryPoints(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, cycleTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddRoot("App", types.NewPointer(pkg.Scope().Lookup("A").Type())))
+
+	errs := sut.Cycles()
+
+	require.Len(t, errs, 2)
+	for _, err := range errs {
+		cycle := err.(*CycleError)
+		if cycle.Cycle[0].Name() == "NewSelf" {
+			assert.Empty(t, cycle.EntryPoints)
+		} else {
+			assert.Equal(t, []string{"App"}, cycle.EntryPoints)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import "go/types"
+
+// A valueNode generates a code fragment to produce an instance of the type of
+// a package-level variable or constant by referring to it. A valueNode does
+// not have any requirements and its one provided type is the type of the
+// variable or constant, qualified by the name of the valueNode (see
+// Qualified).
+type valueNode struct {
+	container *Container
+	id        int
+	value     types.Object
+	name      string
+}
+
+func newValueNode(container *Container, id int, value types.Object) (*valueNode, error) {
+	// Check for a local variable, a parameter or a field.
+	if value.Pkg() == nil || value.Parent() != value.Pkg().Scope() {
+		return nil, newInvalidDeclError(value, "not a package-level declaration")
+	}
+
+	// Check for a value that the builder cannot refer to.
+	if !value.Exported() {
+		return nil, newInvalidDeclError(value, "cannot add unexported values to a Container")
+	}
+
+	// Check for an untyped constant.
+	if basic, ok := value.Type().(*types.Basic); ok && basic.Info()&types.IsUntyped != 0 {
+		return nil, newInvalidDeclError(value, "cannot add untyped constants to a Container")
+	}
+
+	node := &valueNode{
+		container: container,
+		id:        id,
+		value:     value,
+	}
+	return node, nil
+}
+
+func (v valueNode) ID() int {
+	if v.id < 0 {
+		panic("Non singleton nodes cannot have a negative id.")
+	}
+	return v.id
+}
+
+// Generate writes the assignment of the variable or constant to the variable
+// named for its type.
+func (v valueNode) Generate(gen *genContext) {
+	if key := Qualified(v.value.Type(), v.name); gen.isRequired(key) {
+		gen.printf("%s := %s\n", gen.varName(key), gen.objectString(v.value))
+	}
+}
+
+func (v valueNode) requires() []types.Type {
+	return nil
+}
+
+func (v valueNode) provides() []types.Type {
+	return []types.Type{Qualified(v.value.Type(), v.name)}
+}
+
+// setName qualifies the component provided by the value by name.
+func (v *valueNode) setName(name string) {
+	v.name = name
+}
+
+// setParamName returns ErrUnknownParam because a value has no parameters.
+func (v *valueNode) setParamName(param string, name string) error {
+	return ErrUnknownParam
+}
+
+func (v valueNode) getContainer() *Container {
+	return v.container
+}
+
+func (v valueNode) object() types.Object {
+	return v.value
+}
+
+var _ commonNode = valueNode{}
+var _ qualifiedNode = &valueNode{}
@@ -0,0 +1,134 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package depend
+
+import (
+	"bytes"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const valueTestSrc = `package components
+
+type Clock interface {
+	Now() int
+}
+
+type realClock struct{}
+
+func (realClock) Now() int { return 0 }
+
+var DefaultClock Clock = realClock{}
+
+type Port int
+
+const DefaultPort Port = 8080
+
+const BackupPort Port = 9090
+
+var defaultClock Clock = realClock{}
+
+const Untyped = 8080
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+func NewServer(clock Clock, port Port) *Server { return nil }
+`
+
+func TestValueNodeWithNegativeIDPanicsOnID(t *testing.T) {
+	sut := valueNode{id: -1}
+
+	assert.Panics(t, func() { sut.ID() }, "Negative ID did not panic")
+}
+
+func TestValueNodeProvidesTypeOfValue(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	value := pkg.Scope().Lookup("DefaultPort")
+
+	sut, err := newValueNode(nil, 0, value)
+
+	require.NoError(t, err)
+	assert.Empty(t, sut.requires())
+	require.Len(t, sut.provides(), 1)
+	assert.Equal(t, value.Type(), sut.provides()[0])
+}
+
+func TestAddConstOfUntypedConstantIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	sut := &Container{}
+
+	err := sut.AddConst(pkg.Scope().Lookup("Untyped").(*types.Const))
+
+	assert.IsType(t, &InvalidDeclError{}, err)
+}
+
+func TestAddVarOfLocalVariableIsError(t *testing.T) {
+	local := types.NewVar(0, types.NewPackage("path", "mypackage"), "local", types.Typ[types.Int])
+	sut := &Container{}
+
+	err := sut.AddVar(local)
+
+	assert.IsType(t, &InvalidDeclError{}, err)
+}
+
+func TestAddVarOfUnexportedVariableIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	sut := &Container{}
+
+	err := sut.AddVar(pkg.Scope().Lookup("defaultClock").(*types.Var))
+
+	assert.IsType(t, &InvalidDeclError{}, err)
+}
+
+func TestWriteBuilderRefersToValues(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddVar(pkg.Scope().Lookup("DefaultClock").(*types.Var)))
+	require.NoError(t, sut.AddConst(pkg.Scope().Lookup("DefaultPort").(*types.Const)))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "clock := components.DefaultClock\n")
+	assert.Contains(t, out.String(), "port := components.DefaultPort\n")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestWriteBuilderRefersToQualifiedValues(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	sut := &Container{}
+	addConstructors(t, sut, pkg)
+	require.NoError(t, sut.AddVar(pkg.Scope().Lookup("DefaultClock").(*types.Var)))
+	require.NoError(t, sut.AddConst(pkg.Scope().Lookup("DefaultPort").(*types.Const)))
+	require.NoError(t, sut.AddConst(pkg.Scope().Lookup("BackupPort").(*types.Const)))
+	require.NoError(t, sut.Name(pkg.Scope().Lookup("BackupPort"), "backup"))
+	require.NoError(t, sut.NameParam(pkg.Scope().Lookup("NewServer"), "port", "backup"))
+
+	var out bytes.Buffer
+	err := sut.WriteBuilder(&out, BuilderOptions{})
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "backupPort := components.BackupPort\n")
+	assert.Contains(t, out.String(), "components.NewServer(clock, backupPort)")
+	assert.NotContains(t, out.String(), "components.DefaultPort")
+	assert.NoError(t, typecheckGenerated(out.String(), pkg))
+}
+
+func TestNameParamOfValueIsError(t *testing.T) {
+	pkg, _ := loadTestPackage(t, testComponentsPath, valueTestSrc)
+	sut := &Container{}
+	require.NoError(t, sut.AddConst(pkg.Scope().Lookup("DefaultPort").(*types.Const)))
+
+	err := sut.NameParam(pkg.Scope().Lookup("DefaultPort"), "port", "backup")
+
+	assert.Equal(t, ErrUnknownParam, err)
+}
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/tools/go/types/typeutil"
 )
@@ -46,9 +47,37 @@ func (v *varNamer) Name(typ types.Type, instance int) string {
 	return buildFullName(getVarPrefix(typ), name, instance)
 }
 
+// Reserve prevents name from being used as the basename of a variable. A type
+// whose basename would otherwise be name gets a suffixed name instead.
+func (v *varNamer) Reserve(name string) {
+	if len(v.basenameMap[name]) == 0 {
+		v.basenameMap[name] = append(v.basenameMap[name], nil)
+	}
+}
+
 type varBasenameGen uint
 
+// getBasename returns the basename of the variable for typ. The basename for
+// a qualified component is its qualifier followed by the basename for its
+// type, such as "replicaDB" for *sql.DB qualified by "replica".
 func (v *varBasenameGen) getBasename(typ types.Type) string {
+	typ, qualifier := Unqualified(typ)
+	varname := typeBasename(typ)
+	if qualifier != "" {
+		varname = qualifier + toUppercaseLeading(varname)
+	}
+
+	if varname == "" {
+		varname = generateVarName(uint(*v))
+		*v++
+	}
+
+	return varname
+}
+
+// typeBasename returns the basename derived from the name of typ or "" if
+// typ does not give rise to a basename.
+func typeBasename(typ types.Type) string {
 	var named *types.Named
 	var varname string
 
@@ -79,11 +108,6 @@ func (v *varBasenameGen) getBasename(typ types.Type) string {
 		}
 	}
 
-	if varname == "" {
-		varname = generateVarName(uint(*v))
-		*v++
-	}
-
 	return varname
 }
 
@@ -93,6 +117,7 @@ type elemProvider interface {
 }
 
 func getVarPrefix(typ types.Type) string {
+	typ, _ = Unqualified(typ)
 	switch typ.(type) {
 	case *types.Basic:
 		return "b"
@@ -130,6 +155,15 @@ func toLowercaseLeading(str string) string {
 	return out.String()
 }
 
+func toUppercaseLeading(str string) string {
+	first, size := utf8.DecodeRuneInString(str)
+	if size == 0 {
+		return str
+	}
+
+	return string(unicode.ToUpper(first)) + str[size:]
+}
+
 func generateVarName(next uint) string {
 	var out bytes.Buffer
 
@@ -168,7 +202,7 @@ func hasUnderscore(s string) bool {
 
 func findType(typs []types.Type, typ types.Type) (int, bool) {
 	for i := range typs {
-		if types.Identical(typ, typs[i]) {
+		if typs[i] != nil && types.Identical(typ, typs[i]) {
 			return i, true
 		}
 	}
@@ -75,6 +75,27 @@ func TestGetBasename(t *testing.T) {
 	}
 }
 
+func TestGetBasenameIncludesQualifier(t *testing.T) {
+	is := is.New(t)
+	named := makeNamedType("DB", types.Typ[types.Int])
+
+	tests := []struct {
+		expected string
+		typ      types.Type
+	}{
+		{"replicaDB", Qualified(types.NewPointer(named), "replica")},
+		{"primaryInt", Qualified(types.Typ[types.Int], "primary")},
+		{"replica", Qualified(types.NewSignature(nil, nil, nil, false), "replica")},
+	}
+
+	for _, test := range tests {
+		var sut varBasenameGen
+		result := sut.getBasename(test.typ)
+
+		is.Equal(result, test.expected)
+	}
+}
+
 func TestGetBasenameForMaps(t *testing.T) {
 	is := is.New(t)
 	basic1 := types.Typ[types.Int]
@@ -183,3 +204,14 @@ func TestBuildTypeName(t *testing.T) {
 		is.Equal(result, test.expected)
 	}
 }
+
+func TestVarNamerAvoidsReservedNames(t *testing.T) {
+	is := is.New(t)
+	named := makeNamedType("MyInt", types.Typ[types.Int])
+
+	sut := newVarNamer(typeutil.MakeHasher())
+	sut.Reserve("myInt")
+	result := sut.Name(named, 0)
+
+	is.Equal(result, "myInt_A")
+}
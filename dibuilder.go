@@ -0,0 +1,48 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+// Package dibuilder holds the marker types that the constructors scanned by the
+// dibuilder command (see github.com/sbosnick/dibuilder/cmd/dibuilder) embed in
+// their struct parameters and results to change how dibuilder treats those
+// parameters and results.
+package dibuilder
+
+// In marks a struct type that embeds it as a parameter object. A constructor
+// parameter whose type is a parameter object requires the type of each of the
+// exported fields of the struct rather than the struct type itself, and the
+// generated builder passes the constructor a composite literal that sets each
+// of those fields. For example, given
+//
+//	type ServerDeps struct {
+//		dibuilder.In
+//
+//		Config *Config
+//		Logger *Logger
+//	}
+//
+//	func NewServer(deps ServerDeps) *Server
+//
+// NewServer requires a *Config and a *Logger. A field tagged with
+// `dibuilder:"name=replica"` requires the component of its type qualified by
+// the name replica, that is the one provided by a constructor annotated with
+// "//dibuilder:name replica", instead of the unqualified component.
+type In struct{}
+
+// Out marks a struct type that embeds it as a result object. A constructor
+// result whose type is a result object provides the type of each of the
+// exported fields of the struct rather than the struct type itself, and the
+// generated builder assigns each of those fields to its own variable. For
+// example, given
+//
+//	type Outputs struct {
+//		dibuilder.Out
+//
+//		Handler http.Handler
+//		Metrics *Registry
+//	}
+//
+//	func NewOutputs() Outputs
+//
+// NewOutputs provides an http.Handler and a *Registry.
+type Out struct{}
@@ -0,0 +1,348 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package loader
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/sbosnick/dibuilder/depend"
+)
+
+// directivePrefix starts a comment line that annotates the declaration that
+// follows it, such as "//dibuilder:prefer".
+const directivePrefix = "//dibuilder:"
+
+// A directive is an annotation of a declaration. The directive
+// "//dibuilder:name arg1 arg2" has the name "name" and the args "arg1"
+// and "arg2".
+type directive struct {
+	name string
+	args []string
+}
+
+// parseDirectives returns the directives in the doc comment of a declaration.
+func parseDirectives(doc *ast.CommentGroup) []directive {
+	var result []directive
+	if doc == nil {
+		return result
+	}
+
+	for _, comment := range doc.List {
+		if !strings.HasPrefix(comment.Text, directivePrefix) {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(comment.Text, directivePrefix))
+		if len(fields) == 0 {
+			continue
+		}
+		result = append(result, directive{name: fields[0], args: fields[1:]})
+	}
+
+	return result
+}
+
+// hasDirective returns whether directives includes a directive named name.
+func hasDirective(directives []directive, name string) bool {
+	for _, d := range directives {
+		if d.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// declDirectives returns the directives for each top-level declaration in pkg
+// that has at least one directive.
+func declDirectives(pkg *packages.Package) map[types.Object][]directive {
+	result := make(map[types.Object][]directive)
+
+	add := func(ident *ast.Ident, doc *ast.CommentGroup) {
+		if directives := parseDirectives(doc); len(directives) > 0 {
+			if obj := pkg.TypesInfo.Defs[ident]; obj != nil {
+				result[obj] = append(result[obj], directives...)
+			}
+		}
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				add(decl.Name, decl.Doc)
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					doc := decl.Doc
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if spec.Doc != nil {
+							doc = spec.Doc
+						}
+						add(spec.Name, doc)
+					case *ast.ValueSpec:
+						if spec.Doc != nil {
+							doc = spec.Doc
+						}
+						for _, name := range spec.Names {
+							add(name, doc)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// bindDirective binds each of the interface types named by the args of d to
+// the result of function that implements that interface type.
+func bindDirective(container *depend.Container, index packageIndex, pkg *packages.Package, function *types.Func, d directive) error {
+	if len(d.args) == 0 {
+		return newDirectiveError(function, d, "no interface types given")
+	}
+
+	errType := types.Universe.Lookup("error").Type()
+	results := function.Type().(*types.Signature).Results()
+	for _, arg := range d.args {
+		typ := index.lookupType(pkg, function.Pos(), arg)
+		if typ == nil {
+			return newDirectiveError(function, d, arg+" is not a type")
+		}
+		iface, ok := typ.Underlying().(*types.Interface)
+		if !ok {
+			return newDirectiveError(function, d, arg+" is not an interface type")
+		}
+
+		var concrete types.Type
+		for i := 0; i < results.Len(); i++ {
+			typ := results.At(i).Type()
+			if types.Identical(typ, errType) || !types.Implements(typ, iface) {
+				continue
+			}
+			if concrete != nil {
+				return newDirectiveError(function, d, "more than one result implements "+arg)
+			}
+			concrete = typ
+		}
+		if concrete == nil {
+			return newDirectiveError(function, d, "no result implements "+arg)
+		}
+
+		if err := container.Bind(typ, concrete); err != nil {
+			return newDirectiveError(function, d, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// qualifiers records the qualifiers given by the name and param directives so
+// that the parameter name convention can be applied once every constructor
+// has been added to the Container.
+type qualifiers struct {
+	// named are the components qualified by name directives.
+	named []namedComponent
+
+	// explicit are the parameters qualified by param directives.
+	explicit map[*types.Var]bool
+}
+
+// A namedComponent is a component of type typ qualified by name.
+type namedComponent struct {
+	typ  types.Type
+	name string
+}
+
+// nameDirective qualifies the components provided by decl by the one arg of
+// d.
+func nameDirective(container *depend.Container, decl types.Object, d directive, q *qualifiers) error {
+	if len(d.args) != 1 {
+		return newDirectiveError(decl, d, "expected one qualifier")
+	}
+
+	if err := container.Name(decl, d.args[0]); err != nil {
+		return newDirectiveError(decl, d, err.Error())
+	}
+
+	provided, err := container.Provides(decl)
+	if err != nil {
+		return err
+	}
+	for _, typ := range provided {
+		typ, _ = depend.Unqualified(typ)
+		q.named = append(q.named, namedComponent{typ: typ, name: d.args[0]})
+	}
+	return nil
+}
+
+// paramDirective qualifies the components required by the parameters of
+// decl, or by the injected fields of a struct type, named by the args of d.
+// Each arg has the form "param=qualifier".
+func paramDirective(container *depend.Container, decl types.Object, d directive, q *qualifiers) error {
+	if len(d.args) == 0 {
+		return newDirectiveError(decl, d, "no parameters given")
+	}
+
+	for _, arg := range d.args {
+		eq := strings.Index(arg, "=")
+		if eq < 0 {
+			return newDirectiveError(decl, d, arg+" is not of the form param=qualifier")
+		}
+		if err := container.NameParam(decl, arg[:eq], arg[eq+1:]); err != nil {
+			return newDirectiveError(decl, d, arg[:eq]+": "+err.Error())
+		}
+
+		// only the parameters of functions follow the name convention
+		function, ok := decl.(*types.Func)
+		if !ok {
+			continue
+		}
+		params := function.Type().(*types.Signature).Params()
+		for i := 0; i < params.Len(); i++ {
+			if params.At(i).Name() == arg[:eq] {
+				if q.explicit == nil {
+					q.explicit = make(map[*types.Var]bool)
+				}
+				q.explicit[params.At(i)] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// nameParams applies the parameter name convention to functions: a parameter
+// that is not qualified by a param directive and whose name is the qualifier
+// of a component of its type requires that qualified component.
+func (q *qualifiers) nameParams(container *depend.Container, functions []*types.Func) error {
+	for _, function := range functions {
+		params := function.Type().(*types.Signature).Params()
+		for i := 0; i < params.Len(); i++ {
+			param := params.At(i)
+			if q.explicit[param] || !q.isNamed(param.Type(), param.Name()) {
+				continue
+			}
+			if err := container.NameParam(function, param.Name(), param.Name()); err != nil {
+				return fmt.Errorf("parameter %s of %s: %w", param.Name(), function.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isNamed returns whether some name directive qualified a component of type
+// typ by name.
+func (q *qualifiers) isNamed(typ types.Type, name string) bool {
+	for _, component := range q.named {
+		if component.name == name && types.Identical(component.typ, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// A packageIndex holds the loaded packages, including their dependencies,
+// by import path.
+type packageIndex map[string]*packages.Package
+
+func newPackageIndex(pkgs []*packages.Package) packageIndex {
+	index := make(packageIndex)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		index[pkg.PkgPath] = pkg
+	})
+	return index
+}
+
+// lookupType returns the type named by expr or nil if expr does not name a
+// type. expr is evaluated at pos in pkg. If that fails, an expr of the form
+// "path.Name" names the type Name in the loaded package with import path path.
+func (p packageIndex) lookupType(pkg *packages.Package, pos token.Pos, expr string) types.Type {
+	if tv, err := types.Eval(pkg.Fset, pkg.Types, pos, expr); err == nil && tv.IsType() {
+		return tv.Type
+	}
+
+	return p.lookupQualified(expr)
+}
+
+// lookupQualified returns the type named by an expr of the form "path.Name"
+// or nil if there is no such type in the loaded packages.
+func (p packageIndex) lookupQualified(expr string) types.Type {
+	return typeOf(p.qualifiedObject(expr))
+}
+
+// qualifiedObject returns the package-level object named by an expr of the
+// form "path.Name" or nil if there is no such object in the loaded packages.
+func (p packageIndex) qualifiedObject(expr string) types.Object {
+	dot := strings.LastIndex(expr, ".")
+	if dot < 0 {
+		return nil
+	}
+	target, ok := p[expr[:dot]]
+	if !ok || target.Types == nil {
+		return nil
+	}
+
+	return target.Types.Scope().Lookup(expr[dot+1:])
+}
+
+// lookupName returns the type named by name or nil if name does not name
+// exactly one type. name is either of the form "path.Name" or of the form
+// "pkg.Name" where pkg is the name of one of the packages in pkgs, and either
+// form can start with "*" to name the pointer to the type, as in "*pkg.Name".
+func (p packageIndex) lookupName(pkgs []*packages.Package, name string) types.Type {
+	if strings.HasPrefix(name, "*") {
+		if typ := p.lookupName(pkgs, name[1:]); typ != nil {
+			return types.NewPointer(typ)
+		}
+		return nil
+	}
+
+	return typeOf(p.lookupObject(pkgs, name))
+}
+
+// lookupObject returns the package-level object named by name or nil if name
+// does not name exactly one object. name is either of the form "path.Name" or
+// of the form "pkg.Name" where pkg is the name of one of the packages in pkgs.
+func (p packageIndex) lookupObject(pkgs []*packages.Package, name string) types.Object {
+	if obj := p.qualifiedObject(name); obj != nil {
+		return obj
+	}
+
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		return nil
+	}
+	var result types.Object
+	for _, pkg := range pkgs {
+		if pkg.Name != name[:dot] || pkg.Types == nil {
+			continue
+		}
+		if obj := pkg.Types.Scope().Lookup(name[dot+1:]); obj != nil {
+			if result != nil {
+				return nil
+			}
+			result = obj
+		}
+	}
+
+	return result
+}
+
+// typeOf returns the type named by obj or nil if obj is not a type name.
+func typeOf(obj types.Object) types.Type {
+	if typename, ok := obj.(*types.TypeName); ok {
+		return typename.Type()
+	}
+
+	return nil
+}
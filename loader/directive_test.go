@@ -0,0 +1,42 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package loader
+
+import (
+	"go/ast"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirectivesOfNilDocIsEmpty(t *testing.T) {
+	result := parseDirectives(nil)
+
+	assert.Empty(t, result)
+}
+
+func TestParseDirectivesFindsNamesAndArgs(t *testing.T) {
+	doc := &ast.CommentGroup{List: []*ast.Comment{
+		{Text: "// NewThing makes a thing."},
+		{Text: "//dibuilder:prefer"},
+		{Text: "//dibuilder:name replica primary"},
+		{Text: "// dibuilder:ignored"},
+		{Text: "//dibuilder:"},
+	}}
+
+	result := parseDirectives(doc)
+
+	assert.Equal(t, []directive{
+		{name: "prefer", args: []string{}},
+		{name: "name", args: []string{"replica", "primary"}},
+	}, result)
+}
+
+func TestHasDirective(t *testing.T) {
+	directives := []directive{{name: "prefer"}}
+
+	assert.True(t, hasDirective(directives, "prefer"))
+	assert.False(t, hasDirective(directives, "bind"))
+}
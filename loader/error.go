@@ -0,0 +1,79 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package loader
+
+import (
+	"bytes"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/sbosnick/dibuilder/depend"
+)
+
+// LoadError records the errors that prevented the packages that matched the
+// import patterns, or their dependencies, from being loaded or type checked.
+// A LoadError matches ErrLoadFailed with errors.Is. Its message is that of
+// ErrLoadFailed; the individual errors are left to the caller to report.
+type LoadError struct {
+	Errors []packages.Error
+}
+
+func (le *LoadError) Error() string {
+	return ErrLoadFailed.Error()
+}
+
+// Is returns whether target is ErrLoadFailed.
+func (le *LoadError) Is(target error) bool {
+	return target == ErrLoadFailed
+}
+
+// DirectiveError records an invalid "//dibuilder:" directive on a declaration.
+// DirectiveError implements depend.Error.
+type DirectiveError struct {
+	pos       token.Pos
+	directive string
+	declName  string
+	reason    string
+}
+
+func (de *DirectiveError) Error() string {
+	var buffer bytes.Buffer
+	de.writeMessage(&buffer)
+	return buffer.String()
+}
+
+func (de *DirectiveError) Pos() token.Pos {
+	return de.pos
+}
+
+func (de *DirectiveError) ErrorWithPosition(fileSet *token.FileSet) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fileSet.Position(de.pos).String())
+	buffer.WriteString(": ")
+	de.writeMessage(&buffer)
+	return buffer.String()
+}
+
+func (de *DirectiveError) writeMessage(buffer *bytes.Buffer) {
+	buffer.WriteString("Invalid dibuilder:")
+	buffer.WriteString(de.directive)
+	buffer.WriteString(" directive (")
+	buffer.WriteString(de.declName)
+	buffer.WriteString("): ")
+	buffer.WriteString(de.reason)
+}
+
+func newDirectiveError(decl types.Object, d directive, reason string) *DirectiveError {
+	return &DirectiveError{
+		pos:       decl.Pos(),
+		directive: d.name,
+		declName:  decl.Name(),
+		reason:    reason,
+	}
+}
+
+var _ depend.Error = &DirectiveError{}
@@ -0,0 +1,351 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+// Package loader scans Go packages for constructors and adds them to a
+// depend.Container.
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/sbosnick/dibuilder/depend"
+)
+
+// DefaultPrefix is the name prefix of constructors used when a Config does
+// not specify one.
+const DefaultPrefix = "New"
+
+// ErrLoadFailed is the error used to indicate that some of the packages could
+// not be loaded or type checked. The individual errors are recorded in a
+// LoadError that matches ErrLoadFailed.
+var ErrLoadFailed = errors.New("unable to load packages")
+
+// ErrUnknownRoot is the error used to indicate that the root type named in a
+// Config is not a type in the loaded packages.
+var ErrUnknownRoot = errors.New("unknown root type")
+
+const loadMode = packages.NeedName | packages.NeedTypes | packages.NeedSyntax |
+	packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps
+
+// Config controls the loading of packages.
+type Config struct {
+	// Prefix is the name prefix that identifies a constructor. Only exported
+	// top-level functions whose name starts with Prefix are constructors.
+	Prefix string
+
+	// Dir is the directory in which to resolve the import patterns. The
+	// current directory is used if Dir is empty.
+	Dir string
+
+	// Fset is the file set for the positions of the loaded packages. A new
+	// file set is used if Fset is nil.
+	Fset *token.FileSet
+
+	// Root names the root type of the Container, either by the import path
+	// of its package and its name, such as "example.com/myproject/server.Server",
+	// or by the name of a package that matched the import patterns and its
+	// name, such as "server.Server". A leading "*" names the pointer to the
+	// type. The root type is auto-detected if Root and NamedRoots are empty.
+	Root string
+
+	// NamedRoots maps the name of each named root of the Container (see
+	// depend.Container.AddRoot) to its root type, named as for Root.
+	NamedRoots map[string]string
+}
+
+// Result records the outcome of a successful Load.
+type Result struct {
+	// Packages are the packages that matched the import patterns.
+	Packages []*packages.Package
+
+	// Errors are the errors for the declarations that could not be added
+	// to the Container and for the directives that could not be applied.
+	Errors []depend.Error
+}
+
+// Load resolves the import patterns in module mode, type checks the matching
+// packages and adds the constructors in those packages to container.
+//
+// A constructor can be annotated with directives in its doc comment. The
+// directive "//dibuilder:prefer" makes the constructor the preferred provider
+// of its components (see depend.Container.Prefer). The directive
+// "//dibuilder:bind iface..." binds each of the named interface types to the
+// component of the constructor that implements it (see depend.Container.Bind).
+// An interface type is named as in the source file of the constructor or by
+// the import path of its package and its name, such as "io.Writer".
+//
+// The directive "//dibuilder:name qualifier" qualifies the components of the
+// constructor (see depend.Container.Name) so that, for example, two
+// constructors can each provide a distinct *sql.DB. The directive
+// "//dibuilder:param param=qualifier..." makes each of the named parameters of
+// the constructor require the component with the given qualifier (see
+// depend.Container.NameParam). A parameter that is not named in a param
+// directive requires a qualified component if the parameter has the name of
+// the qualifier of a component of its type, such as the parameter replica in
+// "func NewReports(replica *sql.DB) *Reports". A field of a parameter object
+// requires a qualified component if it is tagged with the qualifier (see
+// dibuilder.In).
+//
+// A struct type annotated with the directive "//dibuilder:inject" is added to
+// container as a provider of itself and of the pointer to it (see
+// depend.Container.AddStruct). It can also be annotated with the prefer, name
+// and param directives, where a param directive names injected fields rather
+// than parameters. The bind directive applies only to constructors.
+//
+// A package-level variable or constant annotated with the directive
+// "//dibuilder:provide" is added to container as a provider of its type (see
+// depend.Container.AddVar and depend.Container.AddConst). It can also be
+// annotated with the prefer and name directives.
+//
+// Load collects the depend.Error for a declaration that cannot be added to the
+// container (such as an InvalidFuncError) in the Result and continues with the
+// remaining declarations. Any other error stops the scan. Load returns a
+// LoadError if any of the packages could not be loaded and an error that
+// wraps ErrUnknownRoot if the Root or one of the NamedRoots of config does not
+// name a type.
+func Load(config *Config, container *depend.Container, patterns ...string) (*Result, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: loadMode,
+		Dir:  config.Dir,
+		Fset: config.Fset,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if errs := packageErrors(pkgs); len(errs) > 0 {
+		return nil, &LoadError{Errors: errs}
+	}
+
+	result := &Result{Packages: pkgs}
+	index := newPackageIndex(pkgs)
+	if err := setRoots(config, container, index, pkgs); err != nil {
+		return nil, err
+	}
+	var added []*types.Func
+	var q qualifiers
+	for _, pkg := range pkgs {
+		directives := declDirectives(pkg)
+		for _, function := range constructors(pkg.Types, prefix) {
+			err := container.AddFunc(function)
+			if derr, ok := err.(depend.Error); ok {
+				result.Errors = append(result.Errors, derr)
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			added = append(added, function)
+
+			if err := applyDirectives(container, index, pkg, function, directives[function], &q, result); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, obj := range annotated(pkg.Types, directives, "inject") {
+			err := addStruct(container, obj)
+			if derr, ok := err.(depend.Error); ok {
+				result.Errors = append(result.Errors, derr)
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			if err := applyDirectives(container, index, pkg, obj, directives[obj], &q, result); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, obj := range annotated(pkg.Types, directives, "provide") {
+			err := addValue(container, obj)
+			if derr, ok := err.(depend.Error); ok {
+				result.Errors = append(result.Errors, derr)
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			if err := applyDirectives(container, index, pkg, obj, directives[obj], &q, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := q.nameParams(container, added); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// packageErrors returns the errors of pkgs and of their dependencies.
+func packageErrors(pkgs []*packages.Package) []packages.Error {
+	var errs []packages.Error
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		errs = append(errs, pkg.Errors...)
+	})
+	return errs
+}
+
+// LookupType returns the type named by name in the loaded packages or nil if
+// name does not name exactly one type. name is named as for the Root of a
+// Config.
+func (r *Result) LookupType(name string) types.Type {
+	return newPackageIndex(r.Packages).lookupName(r.Packages, name)
+}
+
+// LookupDecl returns the package-level declaration named by name in the
+// loaded packages or nil if name does not name exactly one declaration. name
+// is either the import path of the package of the declaration and its name,
+// such as "example.com/myproject/cache.NewCache", or the name of a package
+// that matched the import patterns and its name, such as "cache.NewCache".
+func (r *Result) LookupDecl(name string) types.Object {
+	return newPackageIndex(r.Packages).lookupObject(r.Packages, name)
+}
+
+// setRoots sets the root and adds the named roots given in config to
+// container.
+func setRoots(config *Config, container *depend.Container, index packageIndex, pkgs []*packages.Package) error {
+	if config.Root != "" {
+		root := index.lookupName(pkgs, config.Root)
+		if root == nil {
+			return fmt.Errorf("%w: %s", ErrUnknownRoot, config.Root)
+		}
+		if err := container.SetRoot(root); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(config.NamedRoots))
+	for name := range config.NamedRoots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		root := index.lookupName(pkgs, config.NamedRoots[name])
+		if root == nil {
+			return fmt.Errorf("%w: %s", ErrUnknownRoot, config.NamedRoots[name])
+		}
+		if err := container.AddRoot(name, root); err != nil {
+			return fmt.Errorf("root %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyDirectives applies the directives of decl, which has been added to
+// container. The depend.Error for a directive that cannot be applied, such as
+// an unknown directive, is collected in result. Any other error is returned.
+func applyDirectives(container *depend.Container, index packageIndex, pkg *packages.Package, decl types.Object, directives []directive, q *qualifiers, result *Result) error {
+	for _, d := range directives {
+		var err error
+		switch d.name {
+		case "prefer":
+			err = container.Prefer(decl)
+		case "bind":
+			if function, ok := decl.(*types.Func); ok {
+				err = bindDirective(container, index, pkg, function, d)
+			} else {
+				err = newDirectiveError(decl, d, "only constructors can bind interface types")
+			}
+		case "name":
+			err = nameDirective(container, decl, d, q)
+		case "param":
+			err = paramDirective(container, decl, d, q)
+		case "inject", "provide":
+			// applied, or rejected for the kind of decl, when decl is
+			// added to container
+		default:
+			err = newDirectiveError(decl, d, "unknown directive")
+		}
+
+		if derr, ok := err.(depend.Error); ok {
+			result.Errors = append(result.Errors, derr)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// annotated returns the package-level declarations in pkg that are annotated
+// with the directive named name, sorted by name.
+func annotated(pkg *types.Package, directives map[types.Object][]directive, name string) []types.Object {
+	var result []types.Object
+
+	scope := pkg.Scope()
+	for _, declName := range scope.Names() {
+		if obj := scope.Lookup(declName); hasDirective(directives[obj], name) {
+			result = append(result, obj)
+		}
+	}
+
+	return result
+}
+
+// addStruct adds the struct type named by obj, which is annotated with the
+// inject directive, to container.
+func addStruct(container *depend.Container, obj types.Object) error {
+	if named, ok := obj.Type().(*types.Named); ok {
+		if _, isType := obj.(*types.TypeName); isType {
+			return container.AddStruct(named)
+		}
+	}
+
+	return newDirectiveError(obj, directive{name: "inject"}, "not a struct type")
+}
+
+// addValue adds the variable or constant obj, which is annotated with the
+// provide directive, to container.
+func addValue(container *depend.Container, obj types.Object) error {
+	switch obj := obj.(type) {
+	case *types.Var:
+		return container.AddVar(obj)
+	case *types.Const:
+		return container.AddConst(obj)
+	}
+
+	return newDirectiveError(obj, directive{name: "provide"}, "not a variable or constant")
+}
+
+// PackagePath returns the import path of the package in dir or "" if
+// it cannot be determined.
+func PackagePath(dir string) string {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName, Dir: dir}, ".")
+	if err != nil || len(pkgs) != 1 || len(pkgs[0].Errors) > 0 {
+		return ""
+	}
+
+	return pkgs[0].PkgPath
+}
+
+// constructors returns the exported top-level functions in pkg whose
+// name starts with prefix.
+func constructors(pkg *types.Package, prefix string) []*types.Func {
+	var result []*types.Func
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		function, ok := scope.Lookup(name).(*types.Func)
+		if ok && function.Exported() && strings.HasPrefix(name, prefix) {
+			result = append(result, function)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,485 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package loader
+
+import (
+	"errors"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sbosnick/dibuilder/depend"
+)
+
+var testModule = map[string]string{
+	"go.mod": "module example.com/myproject\n\ngo 1.18\n",
+	"components/components.go": `package components
+
+type Config struct{}
+
+type Server struct{}
+
+func (s *Server) Run() {}
+
+func NewConfig() Config { return Config{} }
+
+func NewServer(config Config) *Server { return nil }
+
+func MakeConfig() Config { return Config{} }
+
+func newServer(config Config) *Server { return nil }
+
+func NewGeneric[T any]() T { var t T; return t }
+`,
+	"components/other/other.go": `package other
+
+type Other struct{}
+
+func NewOther() *Other { return nil }
+
+func CreateOther() *Other { return nil }
+`,
+}
+
+// writeTestModule creates a module from files in a temporary directory.
+func writeTestModule(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "loader")
+	require.NoError(t, err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0666))
+	}
+
+	return dir
+}
+
+func TestLoadAddsConstructorsFromMatchingPackages(t *testing.T) {
+	dir := writeTestModule(t, testModule)
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "example.com/myproject/components/...")
+
+	require.NoError(t, err)
+	assert.Len(t, result.Packages, 2)
+	root, err := container.Root()
+	require.NoError(t, err)
+	assert.NotNil(t, root)
+	// NewConfig, NewServer, NewOther, the root node and the missing node
+	assert.Len(t, container.Nodes(), 5)
+}
+
+func TestLoadCollectsInvalidFuncErrors(t *testing.T) {
+	dir := writeTestModule(t, testModule)
+	defer os.RemoveAll(dir)
+	fileSet := token.NewFileSet()
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir, Fset: fileSet}, container, "example.com/myproject/components")
+
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.IsType(t, &depend.InvalidFuncError{}, result.Errors[0])
+	assert.Contains(t, result.Errors[0].ErrorWithPosition(fileSet), "components.go:17")
+}
+
+func TestLoadUsesPrefix(t *testing.T) {
+	dir := writeTestModule(t, testModule)
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	_, err := Load(&Config{Dir: dir, Prefix: "Create"}, container, "example.com/myproject/components/other")
+
+	require.NoError(t, err)
+	// CreateOther and the missing node
+	assert.Len(t, container.Nodes(), 2)
+}
+
+func TestLoadOfBrokenPackageIsError(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod":    "module example.com/myproject\n",
+		"broken.go": "package broken\n\nfunc NewBroken() Undefined { return nil }\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := Load(&Config{Dir: dir}, &depend.Container{}, ".")
+
+	assert.True(t, errors.Is(err, ErrLoadFailed))
+	require.IsType(t, &LoadError{}, err)
+	errs := err.(*LoadError).Errors
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Msg, "Undefined")
+}
+
+func TestPackagePathGivesImportPath(t *testing.T) {
+	dir := writeTestModule(t, testModule)
+	defer os.RemoveAll(dir)
+
+	result := PackagePath(filepath.Join(dir, "components", "other"))
+
+	assert.Equal(t, "example.com/myproject/components/other", result)
+}
+
+func TestLoadPrefersAnnotatedConstructor(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod": "module example.com/myproject\n",
+		"first/first.go": `package first
+
+type Logger struct{}
+
+func NewLogger() *Logger { return nil }
+`,
+		"second/second.go": `package second
+
+import "example.com/myproject/first"
+
+// NewLogger provides the logger used by the application.
+//
+//dibuilder:prefer
+func NewLogger() *first.Logger { return nil }
+
+type App struct{}
+
+func (a *App) Run() {}
+
+func NewApp(logger *first.Logger) *App { return nil }
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	_, err := Load(&Config{Dir: dir}, container, "./...")
+
+	require.NoError(t, err)
+	assert.Empty(t, container.Validate())
+}
+
+var bindTestModule = map[string]string{
+	"go.mod": "module example.com/myproject\n",
+	"sink/sink.go": `package sink
+
+type FileSink struct{}
+
+func (f *FileSink) Write(p []byte) (int, error) { return 0, nil }
+
+//dibuilder:bind io.Writer
+func NewFileSink() (*FileSink, error) { return nil, nil }
+
+//dibuilder:bind io.Reader
+func NewBadSink() *FileSink { return nil }
+`,
+	"app/app.go": `package app
+
+import "io"
+
+type App struct{}
+
+func (a *App) Run() {}
+
+func NewApp(w io.Writer) *App { return nil }
+`,
+}
+
+func TestLoadBindsAnnotatedInterfaces(t *testing.T) {
+	dir := writeTestModule(t, bindTestModule)
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "./...")
+
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Error(), "no result implements io.Reader")
+	// the two sinks are ambiguous for io.Writer
+	errs := container.Validate()
+	require.Len(t, errs, 1)
+	assert.IsType(t, &depend.AmbiguousProviderError{}, errs[0])
+}
+
+func TestResultLookupTypeFindsNamedTypes(t *testing.T) {
+	dir := writeTestModule(t, testModule)
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "example.com/myproject/components/...")
+	require.NoError(t, err)
+
+	byPath := result.LookupType("example.com/myproject/components/other.Other")
+	byName := result.LookupType("other.Other")
+	pointer := result.LookupType("*components.Server")
+	missing := result.LookupType("other.Missing")
+
+	require.NotNil(t, byPath)
+	assert.Equal(t, byPath, byName)
+	require.NotNil(t, pointer)
+	assert.Equal(t, "*example.com/myproject/components.Server", pointer.String())
+	assert.Nil(t, missing)
+}
+
+func TestResultLookupDeclFindsDeclarations(t *testing.T) {
+	dir := writeTestModule(t, testModule)
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "example.com/myproject/components/...")
+	require.NoError(t, err)
+
+	byPath := result.LookupDecl("example.com/myproject/components/other.NewOther")
+	byName := result.LookupDecl("other.NewOther")
+	typ := result.LookupDecl("other.Other")
+	missing := result.LookupDecl("other.Missing")
+
+	require.NotNil(t, byPath)
+	assert.Equal(t, byPath, byName)
+	assert.IsType(t, &types.Func{}, byPath)
+	assert.IsType(t, &types.TypeName{}, typ)
+	assert.Nil(t, missing)
+}
+
+var qualifierTestModule = map[string]string{
+	"go.mod": "module example.com/myproject\n",
+	"db/db.go": `package db
+
+type DB struct{}
+
+//dibuilder:name primary
+func NewPrimary() *DB { return nil }
+
+//dibuilder:name replica
+func NewReplica() *DB { return nil }
+
+//dibuilder:name not-valid
+func NewBackup() *DB { return nil }
+`,
+	"app/app.go": `package app
+
+import "example.com/myproject/db"
+
+type Store struct{}
+
+//dibuilder:param conn=primary
+func NewStore(conn *db.DB) *Store { return nil }
+
+type App struct{}
+
+func (a *App) Run() {}
+
+func NewApp(store *Store, replica *db.DB) *App { return nil }
+`,
+}
+
+func TestLoadQualifiesAnnotatedComponents(t *testing.T) {
+	dir := writeTestModule(t, qualifierTestModule)
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "./...")
+
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Error(), "qualifier is not an identifier")
+	// NewBackup provides the unqualified *db.DB that no constructor requires
+	assert.Empty(t, container.Validate())
+	assert.Len(t, container.Pruned(), 1)
+}
+
+func TestLoadAddsAnnotatedStructs(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod": "module example.com/myproject\n",
+		"app/app.go": `package app
+
+type Config struct{}
+
+func NewConfig() *Config { return nil }
+
+//dibuilder:inject
+type App struct {
+	Config *Config
+}
+
+func (a *App) Run() {}
+
+//dibuilder:inject
+type Port int
+
+type Unused struct {
+	Config *Config
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "./...")
+
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.IsType(t, &depend.InvalidDeclError{}, result.Errors[0])
+	assert.Empty(t, container.Validate())
+	// NewConfig, App, the root node and the missing node
+	assert.Len(t, container.Nodes(), 4)
+}
+
+func TestLoadAppliesDirectivesToAnnotatedStructs(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod": "module example.com/myproject\n",
+		"app/app.go": `package app
+
+type Config struct{}
+
+//dibuilder:name primary
+func NewPrimary() *Config { return nil }
+
+//dibuilder:name replica
+func NewReplica() *Config { return nil }
+
+//dibuilder:inject
+//dibuilder:name main
+//dibuilder:param Config=replica
+type Handler struct {
+	Config *Config
+}
+
+//dibuilder:inject
+//dibuilder:param Handler=main
+//dibuilder:bind fmt.Stringer
+type Server struct {
+	Handler *Handler
+}
+
+func (s *Server) Run() {}
+
+//dibuilder:inject
+type hidden struct{}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "./...")
+
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 2)
+	assert.IsType(t, &DirectiveError{}, result.Errors[0])
+	assert.Contains(t, result.Errors[0].Error(), "only constructors can bind interface types")
+	assert.IsType(t, &depend.InvalidDeclError{}, result.Errors[1])
+	assert.Empty(t, container.Validate())
+	// NewPrimary provides the primary *Config that nothing requires
+	assert.Len(t, container.Pruned(), 1)
+}
+
+func TestLoadAddsAnnotatedValues(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod": "module example.com/myproject\n",
+		"app/app.go": `package app
+
+type Port int
+
+//dibuilder:provide
+const DefaultPort Port = 8080
+
+//dibuilder:provide
+const Untyped = 8080
+
+type Clock struct{}
+
+//dibuilder:provide
+var DefaultClock = &Clock{}
+
+type App struct{}
+
+func (a *App) Run() {}
+
+func NewApp(port Port, clock *Clock) *App { return nil }
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "./...")
+
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.IsType(t, &depend.InvalidDeclError{}, result.Errors[0])
+	assert.Empty(t, container.Validate())
+}
+
+func TestLoadAppliesDirectivesToAnnotatedValues(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod": "module example.com/myproject\n",
+		"app/app.go": `package app
+
+type Port int
+
+//dibuilder:provide
+//dibuilder:name admin
+const AdminPort Port = 9090
+
+//dibuilder:provide
+//dibuilder:prefer
+const DefaultPort Port = 8080
+
+//dibuilder:provide
+const FallbackPort Port = 8000
+
+//dibuilder:provide
+var defaultPort Port = 8080
+
+type App struct{}
+
+func (a *App) Run() {}
+
+func NewApp(port Port, admin Port) *App { return nil }
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "./...")
+
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.IsType(t, &depend.InvalidDeclError{}, result.Errors[0])
+	assert.Empty(t, container.Validate())
+	// FallbackPort is not preferred
+	assert.Len(t, container.Pruned(), 1)
+}
+
+func TestLoadRejectsUnknownAndMisplacedDirectives(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod": "module example.com/myproject\n",
+		"app/app.go": `package app
+
+type Config struct{}
+
+//dibuilder:prefr
+func NewConfig() *Config { return nil }
+
+type App struct{}
+
+func (a *App) Run() {}
+
+//dibuilder:inject
+func NewApp(config *Config) *App { return nil }
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	container := &depend.Container{}
+	result, err := Load(&Config{Dir: dir}, container, "./...")
+
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 2)
+	assert.Contains(t, result.Errors[0].Error(), "Invalid dibuilder:prefr directive (NewConfig): unknown directive")
+	assert.Contains(t, result.Errors[1].Error(), "Invalid dibuilder:inject directive (NewApp): not a struct type")
+}
@@ -0,0 +1,191 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+// Package run runs the root component returned by a builder function
+// generated by dibuilder.
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+)
+
+// A Runner is a component that runs an application. The root component
+// returned by a builder function is a Runner.
+type Runner interface {
+	Run()
+}
+
+// A ContextRunner is a component that runs an application until ctx is
+// cancelled or the application fails. The root component returned by a
+// builder function may be a ContextRunner instead of a Runner.
+type ContextRunner interface {
+	Run(ctx context.Context) error
+}
+
+// ErrInvalidBuilder is the error used to indicate that a value passed as
+// a builder function does not have one of the supported forms.
+var ErrInvalidBuilder = errors.New("invalid builder function")
+
+// Exit codes used by BuildAndRun.
+const (
+	// ExitSuccess is the exit code when the root component finishes running.
+	ExitSuccess = 0
+
+	// ExitFailure is the exit code when the builder function or the root
+	// component fails.
+	ExitFailure = 1
+
+	// exitSignal is added to the number of a signal to give the exit code
+	// when running is interrupted by that signal.
+	exitSignal = 128
+)
+
+var (
+	runnerType        = reflect.TypeOf((*Runner)(nil)).Elem()
+	contextRunnerType = reflect.TypeOf((*ContextRunner)(nil)).Elem()
+	cleanupType       = reflect.TypeOf(func() {})
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// BuildAndRun calls builder to build the root component of the application and
+// then runs that component. builder is a function generated by dibuilder in
+// one of the following forms, where R implements Runner or ContextRunner:
+//
+//	func() R
+//	func() (R, error)
+//	func() (R, func())
+//	func() (R, func(), error)
+//
+// BuildAndRun does not return. It exits with ExitFailure if builder returns an
+// error. Otherwise it exits with ExitSuccess after the root component finishes
+// running or, if the process receives SIGINT or SIGTERM first, with 128 plus
+// the number of the signal. A ContextRunner is passed a context that is
+// cancelled when the signal is received and BuildAndRun waits for it to
+// return before exiting. If it returns an error other than the cancellation
+// of its context then BuildAndRun reports the error and exits with
+// ExitFailure. It calls the cleanup function returned by builder, if any,
+// before exiting.
+func BuildAndRun(builder interface{}) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	os.Exit(buildAndRun(builder, signals, os.Stderr))
+}
+
+// buildAndRun implements BuildAndRun and returns the exit code.
+func buildAndRun(builder interface{}, signals <-chan os.Signal, stderr io.Writer) int {
+	root, cleanup, err := build(builder)
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to build application: %v\n", err)
+		return ExitFailure
+	}
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runRoot(ctx, root)
+	}()
+
+	var code int
+	select {
+	case err := <-done:
+		return exitCode(err, stderr)
+	case sig := <-signals:
+		fmt.Fprintf(stderr, "received %v, exiting\n", sig)
+		code = ExitFailure
+		if sig, ok := sig.(syscall.Signal); ok {
+			code = exitSignal + int(sig)
+		}
+	}
+
+	if _, ok := root.(ContextRunner); !ok {
+		return code
+	}
+
+	cancel()
+	if err := <-done; err != nil && !errors.Is(err, context.Canceled) {
+		return exitCode(err, stderr)
+	}
+	return code
+}
+
+// runRoot runs root, passing ctx to it if it is a ContextRunner.
+func runRoot(ctx context.Context, root interface{}) error {
+	if root, ok := root.(ContextRunner); ok {
+		return root.Run(ctx)
+	}
+
+	root.(Runner).Run()
+	return nil
+}
+
+// exitCode returns the exit code for the error returned by the root
+// component, reporting the error to stderr if it is not nil.
+func exitCode(err error, stderr io.Writer) int {
+	if err != nil {
+		fmt.Fprintf(stderr, "application failed: %v\n", err)
+		return ExitFailure
+	}
+
+	return ExitSuccess
+}
+
+// build calls builder and returns the root component, a cleanup function
+// (which does nothing if builder does not return one) and any error.
+func build(builder interface{}) (interface{}, func(), error) {
+	value := reflect.ValueOf(builder)
+	if !isBuilderType(value) {
+		return nil, nil, ErrInvalidBuilder
+	}
+
+	results := value.Call(nil)
+	cleanup := func() {}
+	for _, result := range results[1:] {
+		switch {
+		case result.Type() == cleanupType:
+			if !result.IsNil() {
+				cleanup = result.Interface().(func())
+			}
+		case !result.IsNil():
+			cleanup()
+			return nil, nil, result.Interface().(error)
+		}
+	}
+
+	return results[0].Interface(), cleanup, nil
+}
+
+// isBuilderType returns whether value is a function with one of the forms
+// supported by BuildAndRun.
+func isBuilderType(value reflect.Value) bool {
+	if value.Kind() != reflect.Func {
+		return false
+	}
+
+	typ := value.Type()
+	if typ.NumIn() != 0 || typ.NumOut() == 0 || typ.NumOut() > 3 {
+		return false
+	}
+	if !typ.Out(0).Implements(runnerType) && !typ.Out(0).Implements(contextRunnerType) {
+		return false
+	}
+
+	switch typ.NumOut() {
+	case 2:
+		return typ.Out(1) == cleanupType || typ.Out(1) == errorType
+	case 3:
+		return typ.Out(1) == cleanupType && typ.Out(2) == errorType
+	}
+	return true
+}
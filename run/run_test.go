@@ -0,0 +1,150 @@
+// Copyright Steven Bosnick 2017. All rights reserved.
+// Use of this source code is governed by the GNU General Public License version 3.
+// See the file COPYING for your rights under that license.
+
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRunner struct {
+	ran   bool
+	block chan struct{}
+}
+
+func (m *mockRunner) Run() {
+	m.ran = true
+	if m.block != nil {
+		<-m.block
+	}
+}
+
+type mockContextRunner struct {
+	err       error
+	waitForIt bool
+	cancelled bool
+}
+
+func (m *mockContextRunner) Run(ctx context.Context) error {
+	if m.waitForIt {
+		<-ctx.Done()
+		m.cancelled = true
+		return ctx.Err()
+	}
+	return m.err
+}
+
+func TestBuildAndRunRunsRoot(t *testing.T) {
+	root := &mockRunner{}
+	var stderr bytes.Buffer
+
+	code := buildAndRun(func() *mockRunner { return root }, nil, &stderr)
+
+	assert.Equal(t, ExitSuccess, code)
+	assert.True(t, root.ran)
+}
+
+func TestBuildAndRunCallsCleanupAfterRun(t *testing.T) {
+	root := &mockRunner{}
+	cleaned := false
+	var stderr bytes.Buffer
+
+	code := buildAndRun(func() (*mockRunner, func(), error) {
+		return root, func() { cleaned = true }, nil
+	}, nil, &stderr)
+
+	assert.Equal(t, ExitSuccess, code)
+	assert.True(t, root.ran)
+	assert.True(t, cleaned)
+}
+
+func TestBuildAndRunReportsBuildError(t *testing.T) {
+	var stderr bytes.Buffer
+
+	code := buildAndRun(func() (*mockRunner, error) {
+		return nil, errors.New("components.NewDB: no database")
+	}, nil, &stderr)
+
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr.String(), "components.NewDB: no database")
+}
+
+func TestBuildAndRunExitsOnSignal(t *testing.T) {
+	root := &mockRunner{block: make(chan struct{})}
+	defer close(root.block)
+	cleaned := false
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+	var stderr bytes.Buffer
+
+	code := buildAndRun(func() (*mockRunner, func()) {
+		return root, func() { cleaned = true }
+	}, signals, &stderr)
+
+	assert.Equal(t, 128+int(syscall.SIGTERM), code)
+	assert.True(t, cleaned)
+}
+
+func TestBuildAndRunRejectsInvalidBuilders(t *testing.T) {
+	builders := []interface{}{
+		nil,
+		42,
+		func() {},
+		func() int { return 0 },
+		func(int) *mockRunner { return nil },
+		func() (*mockRunner, int) { return nil, 0 },
+		func() (*mockRunner, error, func()) { return nil, nil, nil },
+	}
+
+	for _, builder := range builders {
+		var stderr bytes.Buffer
+
+		code := buildAndRun(builder, nil, &stderr)
+
+		assert.Equal(t, ExitFailure, code)
+		assert.Contains(t, stderr.String(), ErrInvalidBuilder.Error())
+	}
+}
+
+func TestBuildAndRunRunsContextRoot(t *testing.T) {
+	root := &mockContextRunner{}
+	var stderr bytes.Buffer
+
+	code := buildAndRun(func() *mockContextRunner { return root }, nil, &stderr)
+
+	assert.Equal(t, ExitSuccess, code)
+}
+
+func TestBuildAndRunReportsContextRootError(t *testing.T) {
+	root := &mockContextRunner{err: errors.New("listen: address in use")}
+	var stderr bytes.Buffer
+
+	code := buildAndRun(func() (*mockContextRunner, error) { return root, nil }, nil, &stderr)
+
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr.String(), "listen: address in use")
+}
+
+func TestBuildAndRunCancelsContextRootOnSignal(t *testing.T) {
+	root := &mockContextRunner{waitForIt: true}
+	cleaned := false
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGINT
+	var stderr bytes.Buffer
+
+	code := buildAndRun(func() (*mockContextRunner, func(), error) {
+		return root, func() { cleaned = true }, nil
+	}, signals, &stderr)
+
+	assert.Equal(t, 128+int(syscall.SIGINT), code)
+	assert.True(t, root.cancelled)
+	assert.True(t, cleaned)
+}